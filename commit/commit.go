@@ -1,17 +1,64 @@
 package commit
 
 type Commit struct {
-	Hash         string              `json:"commitHash"`
-	AuthorName   string              `json:"authorName"`
-	AuthorEmail  string              `json:"authorEmail"`
-	Date         string              `json:"createdAt"`
-	ChangedFiles []*ChangedFile      `json:"changedFiles"`
-	Libraries    map[string][]string `json:"libraries"`
+	Hash           string              `json:"commitHash"`
+	AuthorName     string              `json:"authorName"`
+	AuthorEmail    string              `json:"authorEmail"`
+	AuthorDomain   string              `json:"authorDomain"` // Domain of AuthorEmail, empty if the email has none
+	Date           string              `json:"createdAt"`
+	CommitterDate  string              `json:"committedAt"` // Differs from the author date e.g. after a rebase
+	Subject        string              `json:"subject"`
+	Body           string              `json:"body"`
+	ChangedFiles   []*ChangedFile      `json:"changedFiles"`
+	Libraries      map[string][]string `json:"libraries"`
+	Reviewers      []Author            `json:"reviewers"`
+	CoAuthors      []Author            `json:"coAuthors"`     // From the Co-authored-by trailers, e.g. of pair programming
+	References     []string            `json:"references"`    // Issues and pull requests mentioned in the message
+	LanguageCount  int                 `json:"languageCount"` // Number of distinct languages of the changed files
+	FilesAdded     int                 `json:"filesAdded"`
+	FilesDeleted   int                 `json:"filesDeleted"`
+	FilesModified  int                 `json:"filesModified"`            // Renamed files are modified too
+	WeightedScore  float64             `json:"weightedScore"`            // Changed lines weighted by the paths of the files
+	Orphaned       bool                `json:"orphaned,omitempty"`       // No ref reaches the commit, e.g. after a force push
+	IsLikelySquash bool                `json:"isLikelySquash,omitempty"` // Looks like a squash merge, set by DetectSquashes
+}
+
+// Author is a person mentioned in the commit, like a reviewer
+type Author struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
 }
 
 type ChangedFile struct {
 	Path       string `json:"fileName"`
 	Insertions int    `json:"insertions"`
 	Deletions  int    `json:"deletions"`
+	IsBinary   bool   `json:"isBinary,omitempty"` // git shows no lines of binary files
 	Language   string `json:"language"`
+	Category   string `json:"category,omitempty"` // E.g. "doc" for documentation
+	ChangeType string `json:"changeType"`         // One of ChangeAdded, ChangeDeleted, ChangeModified and ChangeRenamed
+	BlobHash   string `json:"blobHash,omitempty"` // Hash of the contents after the commit, empty for deleted files
+}
+
+// Change types of the changed files
+const (
+	ChangeAdded    = "added"
+	ChangeDeleted  = "deleted"
+	ChangeModified = "modified"
+	ChangeRenamed  = "renamed"
+)
+
+// CountChangedFiles sets the number of added, deleted and modified files from the change types
+func (c *Commit) CountChangedFiles() {
+	c.FilesAdded, c.FilesDeleted, c.FilesModified = 0, 0, 0
+	for _, f := range c.ChangedFiles {
+		switch f.ChangeType {
+		case ChangeAdded:
+			c.FilesAdded++
+		case ChangeDeleted:
+			c.FilesDeleted++
+		default:
+			c.FilesModified++
+		}
+	}
 }
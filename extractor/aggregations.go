@@ -0,0 +1,471 @@
+package extractor
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/languagedetection"
+)
+
+// DirCount is the number of changed files in a directory
+type DirCount struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// aggregate calculates the repo level statistics from the user's commits
+func (r *RepoExtractor) aggregate() {
+	r.repo.TopDirectories = topDirectories(r.userCommits, r.groupDepth())
+	r.repo.FirstCommitAt, r.repo.LastCommitAt = commitRange(r.userCommits)
+	average, median := commitGaps(r.userCommits, r.InactivityThreshold)
+	r.repo.AverageCommitGap = int64(average.Seconds())
+	r.repo.MedianCommitGap = int64(median.Seconds())
+	r.repo.WeeklyActivity = weeklyActivity(r.userCommits)
+	r.repo.TimeZoneSource = r.TimeZoneSource
+	if r.repo.TimeZoneSource == "" {
+		r.repo.TimeZoneSource = DateSourceAuthor
+	}
+	r.repo.TimeZones = timeZones(r.userCommits, r.repo.TimeZoneSource)
+	r.repo.LanguageSummary = languageSummary(r.userCommits)
+	r.repo.LanguageAddDelete = languageAddDelete(r.repo.LanguageSummary)
+	r.repo.PrimaryLanguages = primaryLanguages(r.repo.LanguageAddDelete, primaryLanguageCount)
+	r.repo.AuthorStats = authorStats(r.userCommits)
+	r.repo.MaxLanguagesInCommit = 0
+	languageAnalyzer := languagedetection.NewLanguageAnalyzer()
+	for _, c := range r.userCommits {
+		c.LanguageCount = languageCount(languageAnalyzer, c)
+		if c.LanguageCount > r.repo.MaxLanguagesInCommit {
+			r.repo.MaxLanguagesInCommit = c.LanguageCount
+		}
+	}
+}
+
+// languageCount returns the number of distinct languages among the changed files
+// The languages are detected like in languageSummary, the files of an unknown language aren't counted.
+func languageCount(languageAnalyzer *languagedetection.LanguageAnalyzer, c *commit.Commit) int {
+	languages := map[string]bool{}
+	for _, f := range c.ChangedFiles {
+		if lang := fileLanguage(languageAnalyzer, f); lang != "" {
+			languages[lang] = true
+		}
+	}
+	return len(languages)
+}
+
+// commitTime parses the date of the commit
+func commitTime(c *commit.Commit) (time.Time, bool) {
+	t, err := time.Parse(dateFormat, c.Date)
+	return t, err == nil
+}
+
+// commitRange returns the dates of the earliest and the latest commits as they are in the commits
+// They are compared as instants, whatever their time zones are. Both are empty without valid dates.
+func commitRange(commits []*commit.Commit) (string, string) {
+	var first, last *commit.Commit
+	var firstTime, lastTime time.Time
+	for _, c := range commits {
+		t, ok := commitTime(c)
+		if !ok {
+			continue
+		}
+		if first == nil || t.Before(firstTime) {
+			first, firstTime = c, t
+		}
+		if last == nil || t.After(lastTime) {
+			last, lastTime = c, t
+		}
+	}
+	if first == nil {
+		return "", ""
+	}
+	return first.Date, last.Date
+}
+
+// commitGaps returns the average and the median time between consecutive commits
+// Gaps longer than inactivityThreshold are skipped if it's greater than zero.
+func commitGaps(commits []*commit.Commit, inactivityThreshold time.Duration) (time.Duration, time.Duration) {
+	times := make([]time.Time, 0, len(commits))
+	for _, c := range commits {
+		if t, ok := commitTime(c); ok {
+			times = append(times, t)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	gaps := []time.Duration{}
+	for i := 1; i < len(times); i++ {
+		gap := times[i].Sub(times[i-1])
+		if inactivityThreshold > 0 && gap > inactivityThreshold {
+			continue
+		}
+		gaps = append(gaps, gap)
+	}
+	if len(gaps) == 0 {
+		return 0, 0
+	}
+
+	var total time.Duration
+	for _, gap := range gaps {
+		total += gap
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		return gaps[i] < gaps[j]
+	})
+	median := gaps[len(gaps)/2]
+	if len(gaps)%2 == 0 {
+		median = (gaps[len(gaps)/2-1] + median) / 2
+	}
+	return total / time.Duration(len(gaps)), median
+}
+
+// DefaultGroupDepth is used if GroupDepth is zero
+const DefaultGroupDepth = 1
+
+// groupDepth returns the depth of the directories in the directory aggregations
+func (r *RepoExtractor) groupDepth() int {
+	if r.GroupDepth == 0 {
+		return DefaultGroupDepth
+	}
+	return r.GroupDepth
+}
+
+// topDirectories counts the changed files by directory, the most active directory comes first.
+// Directories are cut after depth levels, e.g. src/a/b.go belongs to src at depth 1.
+// Files in the root of the repo belong to ".".
+func topDirectories(commits []*commit.Commit, depth int) []DirCount {
+	counts := map[string]int{}
+	for _, c := range commits {
+		for _, f := range c.ChangedFiles {
+			counts[directoryAtDepth(f.Path, depth)]++
+		}
+	}
+
+	dirCounts := make([]DirCount, 0, len(counts))
+	for dir, count := range counts {
+		dirCounts = append(dirCounts, DirCount{Path: dir, Count: count})
+	}
+	sort.Slice(dirCounts, func(i, j int) bool {
+		if dirCounts[i].Count != dirCounts[j].Count {
+			return dirCounts[i].Count > dirCounts[j].Count
+		}
+		return dirCounts[i].Path < dirCounts[j].Path
+	})
+	return dirCounts
+}
+
+// directoryAtDepth returns the directory of filePath cut after depth levels
+func directoryAtDepth(filePath string, depth int) string {
+	dir := path.Dir(filePath)
+	if dir == "." || depth <= 0 {
+		return dir
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// dominatedFiles returns the files where the user changed more lines than anybody else
+// The user is identified by any of userEmails. Ties are not considered dominated.
+func dominatedFiles(commits []*commit.Commit, userEmails map[string]bool) []string {
+	const user = "" // Key of the user in the per author sums, emails can't be empty
+	linesByFile := map[string]map[string]int{}
+	for _, c := range commits {
+		author := c.AuthorEmail
+		if userEmails[author] {
+			author = user
+		}
+		for _, f := range c.ChangedFiles {
+			if linesByFile[f.Path] == nil {
+				linesByFile[f.Path] = map[string]int{}
+			}
+			linesByFile[f.Path][author] += f.Insertions + f.Deletions
+		}
+	}
+
+	files := []string{}
+	for file, linesByAuthor := range linesByFile {
+		userLines, ok := linesByAuthor[user]
+		if !ok || userLines == 0 {
+			continue
+		}
+		dominated := true
+		for author, lines := range linesByAuthor {
+			if author != user && lines >= userLines {
+				dominated = false
+				break
+			}
+		}
+		if dominated {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// WeekStat is the activity of the user in an ISO week
+type WeekStat struct {
+	Week     string `json:"week"`      // ISO week, e.g. 2021-W01
+	Start    string `json:"weekStart"` // Date of the Monday starting the week
+	Commits  int    `json:"commits"`
+	NetLines int    `json:"netLines"` // Insertions minus deletions
+}
+
+// weeklyActivity buckets the commits by the ISO week of their dates, the oldest week first
+// The weeks are in the time zones of the commits.
+func weeklyActivity(commits []*commit.Commit) []WeekStat {
+	weeks := []WeekStat{}
+	indexes := map[string]int{}
+	for _, c := range commits {
+		t, ok := commitTime(c)
+		if !ok {
+			continue
+		}
+		year, week := t.ISOWeek()
+		key := fmt.Sprintf("%04d-W%02d", year, week)
+		i, ok := indexes[key]
+		if !ok {
+			i = len(weeks)
+			indexes[key] = i
+			start := t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+			weeks = append(weeks, WeekStat{Week: key, Start: start.Format("2006-01-02")})
+		}
+		weeks[i].Commits++
+		for _, f := range c.ChangedFiles {
+			weeks[i].NetLines += f.Insertions - f.Deletions
+		}
+	}
+	sort.Slice(weeks, func(i, j int) bool {
+		return weeks[i].Week < weeks[j].Week
+	})
+	return weeks
+}
+
+// languagesIntroduced returns the languages which the user committed before anybody else
+// The user is identified by any of userEmails. Like in the leaderboard the languages come from the extensions.
+// If somebody else used the language at the same time it is not introduced by the user.
+func languagesIntroduced(commits []*commit.Commit, userEmails map[string]bool) []string {
+	languageAnalyzer := languagedetection.NewLanguageAnalyzer()
+	userFirst := map[string]time.Time{}
+	othersFirst := map[string]time.Time{}
+	for _, c := range commits {
+		t, ok := commitTime(c)
+		if !ok {
+			continue
+		}
+		first := othersFirst
+		if userEmails[c.AuthorEmail] {
+			first = userFirst
+		}
+		for _, f := range c.ChangedFiles {
+			lang := fileLanguage(languageAnalyzer, f)
+			if lang == "" {
+				continue
+			}
+			if current, ok := first[lang]; !ok || t.Before(current) {
+				first[lang] = t
+			}
+		}
+	}
+
+	languages := []string{}
+	for lang, t := range userFirst {
+		if others, ok := othersFirst[lang]; !ok || t.Before(others) {
+			languages = append(languages, lang)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// ContributorStat is the activity of an author of the repo
+type ContributorStat struct {
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	Commits   int      `json:"commits"`
+	NetLines  int      `json:"netLines"` // Insertions minus deletions
+	Languages []string `json:"languages"`
+}
+
+// AuthorStats is the activity of one of the user's emails
+type AuthorStats struct {
+	Commits    int `json:"commits"`
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+	Files      int `json:"files"` // Distinct paths changed by the commits
+}
+
+// StatsByAuthor returns the commits and the lines of the user by author email
+// The emails are hashed if Obfuscate is set. It is empty before the extraction.
+func (r *RepoExtractor) StatsByAuthor() map[string]AuthorStats {
+	if r.repo == nil {
+		return nil
+	}
+	return r.repo.AuthorStats
+}
+
+// authorStats sums the commits, the lines and the changed files by author email
+func authorStats(commits []*commit.Commit) map[string]AuthorStats {
+	stats := map[string]AuthorStats{}
+	files := map[string]map[string]bool{}
+	for _, c := range commits {
+		stat := stats[c.AuthorEmail]
+		if files[c.AuthorEmail] == nil {
+			files[c.AuthorEmail] = map[string]bool{}
+		}
+		stat.Commits++
+		for _, f := range c.ChangedFiles {
+			stat.Insertions += f.Insertions
+			stat.Deletions += f.Deletions
+			files[c.AuthorEmail][f.Path] = true
+		}
+		stat.Files = len(files[c.AuthorEmail])
+		stats[c.AuthorEmail] = stat
+	}
+	return stats
+}
+
+// leaderboard returns the stats of every author, the most commits first
+// The libraries are only detected for the user's commits, so the languages come from the extensions.
+func leaderboard(commits []*commit.Commit) []ContributorStat {
+	languageAnalyzer := languagedetection.NewLanguageAnalyzer()
+	stats := []ContributorStat{}
+	indexes := map[string]int{}
+	languages := []map[string]bool{}
+	for _, c := range commits {
+		i, ok := indexes[c.AuthorEmail]
+		if !ok {
+			i = len(stats)
+			indexes[c.AuthorEmail] = i
+			stats = append(stats, ContributorStat{Name: c.AuthorName, Email: c.AuthorEmail})
+			languages = append(languages, map[string]bool{})
+		}
+		stats[i].Commits++
+		for _, f := range c.ChangedFiles {
+			stats[i].NetLines += f.Insertions - f.Deletions
+			lang := fileLanguage(languageAnalyzer, f)
+			if lang != "" {
+				languages[i][lang] = true
+			}
+		}
+	}
+	for i := range stats {
+		stats[i].Languages = []string{}
+		for lang := range languages[i] {
+			stats[i].Languages = append(stats[i].Languages, lang)
+		}
+		sort.Strings(stats[i].Languages)
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Commits != stats[j].Commits {
+			return stats[i].Commits > stats[j].Commits
+		}
+		return stats[i].Email < stats[j].Email
+	})
+	return stats
+}
+
+// fileLanguage returns the language detected by the library detection, otherwise the language of the extension
+func fileLanguage(languageAnalyzer *languagedetection.LanguageAnalyzer, f *commit.ChangedFile) string {
+	if f.Language != "" {
+		return f.Language
+	}
+	return languageAnalyzer.DetectLanguageFromExtension(strings.ToLower(strings.TrimPrefix(filepath.Ext(f.Path), ".")))
+}
+
+// AddDelete is the number of inserted and deleted lines
+type AddDelete struct {
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// languageAddDelete returns the inserted and deleted lines of the languages of the summary
+// The files without a known language and the languages without changed lines, e.g. of binary files, are left out.
+func languageAddDelete(summary map[string]LanguageStat) map[string]AddDelete {
+	totals := map[string]AddDelete{}
+	for lang, stat := range summary {
+		if lang == UnknownLanguage || (stat.Insertions == 0 && stat.Deletions == 0) {
+			continue
+		}
+		totals[lang] = AddDelete{Insertions: stat.Insertions, Deletions: stat.Deletions}
+	}
+	return totals
+}
+
+// primaryLanguageCount is the number of languages in PrimaryLanguages
+const primaryLanguageCount = 3
+
+// PrimaryLanguages returns the languages where the user changed the most lines, the first one the most
+// Files without a known language are left out. It is empty before the extraction.
+func (r *RepoExtractor) PrimaryLanguages() []string {
+	if r.repo == nil {
+		return nil
+	}
+	return r.repo.PrimaryLanguages
+}
+
+// primaryLanguages returns the n languages with the most inserted and deleted lines
+// Languages with the same number of lines are sorted by name.
+func primaryLanguages(totals map[string]AddDelete, n int) []string {
+	languages := make([]string, 0, len(totals))
+	for lang := range totals {
+		languages = append(languages, lang)
+	}
+	lines := func(lang string) int {
+		return totals[lang].Insertions + totals[lang].Deletions
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if lines(languages[i]) != lines(languages[j]) {
+			return lines(languages[i]) > lines(languages[j])
+		}
+		return languages[i] < languages[j]
+	})
+	if len(languages) > n {
+		languages = languages[:n]
+	}
+	return languages
+}
+
+// UnknownLanguage is the key of the files without a known language in LanguageSummary
+const UnknownLanguage = "Unknown"
+
+// LanguageStat is the activity of the user in a language
+type LanguageStat struct {
+	Commits    int `json:"commits"` // Commits changing at least one file of the language
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// languageSummary sums the commits and the changed lines by language
+// It counts every file, the ones without a language under UnknownLanguage. languageAddDelete is derived from it.
+func languageSummary(commits []*commit.Commit) map[string]LanguageStat {
+	languageAnalyzer := languagedetection.NewLanguageAnalyzer()
+	summary := map[string]LanguageStat{}
+	for _, c := range commits {
+		counted := map[string]bool{}
+		for _, f := range c.ChangedFiles {
+			lang := fileLanguage(languageAnalyzer, f)
+			if lang == "" {
+				lang = UnknownLanguage
+			}
+			stat := summary[lang]
+			if !counted[lang] {
+				counted[lang] = true
+				stat.Commits++
+			}
+			stat.Insertions += f.Insertions
+			stat.Deletions += f.Deletions
+			summary[lang] = stat
+		}
+	}
+	return summary
+}
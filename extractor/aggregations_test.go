@@ -0,0 +1,500 @@
+package extractor_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+// newCommit creates a commit changing the given files
+func newCommit(paths ...string) *commit.Commit {
+	c := &commit.Commit{ChangedFiles: []*commit.ChangedFile{}}
+	for _, p := range paths {
+		c.ChangedFiles = append(c.ChangedFiles, &commit.ChangedFile{Path: p, Insertions: 1})
+	}
+	return c
+}
+
+var _ = Describe("TopDirectories", func() {
+	It("should rank the directories by the number of changed files", func() {
+		commits := []*commit.Commit{
+			newCommit("src/a/main.go", "src/b/util.go", "README.md"),
+			newCommit("docs/usage.md", "src/a/main.go"),
+			newCommit("docs/install.md", "Makefile", "test/main_test.go"),
+		}
+		Expect(extractor.TopDirectories(commits, 1)).To(Equal([]extractor.DirCount{
+			{Path: "src", Count: 3},
+			{Path: ".", Count: 2},
+			{Path: "docs", Count: 2},
+			{Path: "test", Count: 1},
+		}))
+	})
+
+	It("should group the directories at GroupDepth", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		repo.commit(testCommit{Files: map[string]string{
+			"src/a/b/c.go": "package b\n",
+			"src/a/d.go":   "package a\n",
+			"src/e/f.go":   "package e\n",
+		}})
+
+		topDirectories := func(depth int) []interface{} {
+			metadata, _ := extractRepo(repo, func(re *extractor.RepoExtractor) {
+				re.SkipLibraries = true
+				re.GroupDepth = depth
+			})
+			return metadata["topDirectories"].([]interface{})
+		}
+		Expect(topDirectories(0)).To(Equal([]interface{}{
+			map[string]interface{}{"path": "src", "count": 3.0},
+		}))
+		Expect(topDirectories(2)).To(Equal([]interface{}{
+			map[string]interface{}{"path": "src/a", "count": 2.0},
+			map[string]interface{}{"path": "src/e", "count": 1.0},
+		}))
+	})
+
+	It("should fail for negative GroupDepth", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, GroupDepth: -1}
+		Expect(re.Extract()).To(MatchError("group depth must not be negative, got -1"))
+	})
+})
+
+var _ = Describe("CommitGaps", func() {
+	newCommitAt := func(date string) *commit.Commit {
+		return &commit.Commit{Date: date}
+	}
+
+	commits := []*commit.Commit{
+		newCommitAt("2021-01-01 12:00:00 +0000"),
+		newCommitAt("2021-01-01 10:00:00 +0000"),
+		newCommitAt("2021-01-01 11:00:00 +0000"),
+		newCommitAt("2021-01-01 15:00:00 +0100"), // 14:00 UTC
+		newCommitAt("2021-01-11 14:00:00 +0000"),
+		newCommitAt("invalid"),
+	}
+
+	It("should calculate the average and median gap", func() {
+		average, median := extractor.CommitGaps(commits, 0)
+		// Gaps: 1h, 1h, 2h, 240h
+		Expect(average).To(Equal(61 * time.Hour))
+		Expect(median).To(Equal(90 * time.Minute))
+	})
+
+	It("should skip the gaps longer than the threshold", func() {
+		average, median := extractor.CommitGaps(commits, 24*time.Hour)
+		Expect(average).To(Equal(80 * time.Minute))
+		Expect(median).To(Equal(time.Hour))
+	})
+
+	It("should return zero without gaps", func() {
+		average, median := extractor.CommitGaps(commits[:1], 0)
+		Expect(average).To(BeZero())
+		Expect(median).To(BeZero())
+	})
+})
+
+var _ = Describe("CommitRange", func() {
+	newCommitAt := func(date string) *commit.Commit {
+		return &commit.Commit{Date: date}
+	}
+
+	It("should return the dates of the earliest and the latest commits", func() {
+		first, last := extractor.CommitRange([]*commit.Commit{
+			newCommitAt("2021-01-01 12:00:00 +0000"),
+			newCommitAt("2021-01-01 10:30:00 +0100"), // 09:30 UTC
+			newCommitAt("invalid"),
+			newCommitAt("2021-01-11 14:00:00 +0000"),
+			newCommitAt("2021-01-11 23:00:00 +0200"), // 21:00 UTC
+			newCommitAt("2021-01-01 10:00:00 +0000"),
+		})
+		Expect(first).To(Equal("2021-01-01 10:30:00 +0100"))
+		Expect(last).To(Equal("2021-01-11 23:00:00 +0200"))
+	})
+
+	It("should return empty dates without commits", func() {
+		first, last := extractor.CommitRange(nil)
+		Expect(first).To(BeEmpty())
+		Expect(last).To(BeEmpty())
+		first, last = extractor.CommitRange([]*commit.Commit{newCommitAt("invalid")})
+		Expect(first).To(BeEmpty())
+		Expect(last).To(BeEmpty())
+	})
+})
+
+var _ = Describe("DominatedFiles", func() {
+	newCommitBy := func(email string, lines map[string]int) *commit.Commit {
+		c := &commit.Commit{AuthorEmail: email, ChangedFiles: []*commit.ChangedFile{}}
+		for path, n := range lines {
+			c.ChangedFiles = append(c.ChangedFiles, &commit.ChangedFile{Path: path, Insertions: n, Deletions: 1})
+		}
+		return c
+	}
+
+	It("should find the files where the user changed the most lines", func() {
+		commits := []*commit.Commit{
+			newCommitBy("me@work.com", map[string]int{"main.go": 10, "util.go": 2, "tie.go": 4}),
+			newCommitBy("me@home.com", map[string]int{"util.go": 5}),
+			newCommitBy("other@example.com", map[string]int{"main.go": 3, "util.go": 8, "tie.go": 4, "other.go": 1}),
+		}
+		userEmails := map[string]bool{"me@work.com": true, "me@home.com": true}
+		// util.go: 3+6 lines by the user vs 9 by the other author
+		Expect(extractor.DominatedFiles(commits, userEmails)).To(Equal([]string{"main.go"}))
+		commits = append(commits, newCommitBy("me@home.com", map[string]int{"util.go": 0}))
+		Expect(extractor.DominatedFiles(commits, userEmails)).To(Equal([]string{"main.go", "util.go"}))
+	})
+})
+
+var _ = Describe("LanguagesIntroduced", func() {
+	newCommitBy := func(email, date string, paths ...string) *commit.Commit {
+		c := &commit.Commit{AuthorEmail: email, Date: date, ChangedFiles: []*commit.ChangedFile{}}
+		for _, p := range paths {
+			c.ChangedFiles = append(c.ChangedFiles, &commit.ChangedFile{Path: p, Insertions: 1})
+		}
+		return c
+	}
+
+	It("should find the languages which the user committed first", func() {
+		commits := []*commit.Commit{
+			newCommitBy("other@example.com", "2020-01-03 10:00:00 +0000", "lib.rs", "b.py"),
+			newCommitBy("me@work.com", "2020-01-02 10:00:00 +0000", "main.go", "app.ts"),
+			newCommitBy("me@home.com", "2020-01-02 12:00:00 +0100", "main.rs"),
+			newCommitBy("other@example.com", "2020-01-01 10:00:00 +0000", "old.go"),
+			newCommitBy("other@example.com", "2020-01-02 11:00:00 +0000", "tie.py"),
+			newCommitBy("me@work.com", "2020-01-02 11:00:00 +0000", "a.py"),
+			newCommitBy("me@work.com", "invalid", "main.c"),
+		}
+		userEmails := map[string]bool{"me@work.com": true, "me@home.com": true}
+		Expect(extractor.LanguagesIntroduced(commits, userEmails)).To(Equal([]string{"Rust", "TypeScript"}))
+	})
+})
+
+var _ = Describe("WeeklyActivity", func() {
+	newCommitAt := func(date string, insertions int) *commit.Commit {
+		return &commit.Commit{Date: date, ChangedFiles: []*commit.ChangedFile{{Path: "main.go", Insertions: insertions, Deletions: 1}}}
+	}
+
+	It("should bucket the commits by ISO week across year boundaries", func() {
+		commits := []*commit.Commit{
+			newCommitAt("2021-01-04 09:00:00 +0000", 5), // Monday of 2021-W01
+			newCommitAt("2020-12-31 10:00:00 +0000", 3), // Thursday of 2020-W53
+			newCommitAt("2021-01-03 23:30:00 +0100", 2), // Sunday of 2020-W53 in its own time zone
+			newCommitAt("2019-12-30 08:00:00 +0000", 1), // Monday of 2020-W01
+			newCommitAt("invalid", 100),
+		}
+		Expect(extractor.WeeklyActivity(commits)).To(Equal([]extractor.WeekStat{
+			{Week: "2020-W01", Start: "2019-12-30", Commits: 1, NetLines: 0},
+			{Week: "2020-W53", Start: "2020-12-28", Commits: 2, NetLines: 3},
+			{Week: "2021-W01", Start: "2021-01-04", Commits: 1, NetLines: 4},
+		}))
+	})
+})
+
+var _ = Describe("Leaderboard", func() {
+	newCommitBy := func(name, email string, insertions, deletions int, paths ...string) *commit.Commit {
+		c := &commit.Commit{AuthorName: name, AuthorEmail: email, ChangedFiles: []*commit.ChangedFile{}}
+		for _, p := range paths {
+			c.ChangedFiles = append(c.ChangedFiles, &commit.ChangedFile{Path: p, Insertions: insertions, Deletions: deletions})
+		}
+		return c
+	}
+
+	It("should rank the authors by the number of commits", func() {
+		commits := []*commit.Commit{
+			newCommitBy("Bob", "bob@example.com", 10, 2, "main.go"),
+			newCommitBy("Alice", "alice@example.com", 5, 0, "app.py", "README.md"),
+			newCommitBy("Alice", "alice@example.com", 1, 4, "server.go"),
+			newCommitBy("Carol", "carol@example.com", 3, 0, "index.js"),
+			newCommitBy("Alice", "alice@example.com", 0, 1, "app.py"),
+			newCommitBy("Bob", "bob@example.com", 2, 2, "util.go"),
+		}
+		Expect(extractor.Leaderboard(commits)).To(Equal([]extractor.ContributorStat{
+			{Name: "Alice", Email: "alice@example.com", Commits: 3, NetLines: 6, Languages: []string{"Go", "Python"}},
+			{Name: "Bob", Email: "bob@example.com", Commits: 2, NetLines: 8, Languages: []string{"Go"}},
+			{Name: "Carol", Email: "carol@example.com", Commits: 1, NetLines: 3, Languages: []string{"JavaScript"}},
+		}))
+	})
+})
+
+var _ = Describe("LanguageAddDelete", func() {
+	It("should sum the lines by language", func() {
+		commits := []*commit.Commit{
+			{ChangedFiles: []*commit.ChangedFile{
+				{Path: "main.go", Insertions: 10, Deletions: 2, Language: "Go"},
+				{Path: "app.py", Insertions: 1, Deletions: 5},
+				{Path: "logo.png"},
+			}},
+			{ChangedFiles: []*commit.ChangedFile{
+				{Path: "util.go", Insertions: 3, Deletions: 4},
+				{Path: "README.md", Insertions: 7},
+				{Path: "icon.svg.go", Language: "Go"}, // binary
+			}},
+		}
+		summary := extractor.LanguageSummary(commits)
+		Expect(extractor.LanguageAddDelete(summary)).To(Equal(map[string]extractor.AddDelete{
+			"Go":     {Insertions: 13, Deletions: 6},
+			"Python": {Insertions: 1, Deletions: 5},
+		}))
+		// Both count the same lines
+		Expect(summary["Go"].Insertions).To(Equal(13))
+		Expect(summary["Go"].Deletions).To(Equal(6))
+	})
+})
+
+var _ = Describe("LanguageSummary", func() {
+	It("should sum the commits and lines by language", func() {
+		commits := []*commit.Commit{
+			{ChangedFiles: []*commit.ChangedFile{
+				{Path: "main.go", Insertions: 10, Deletions: 2, Language: "Go"},
+				{Path: "util.go", Insertions: 1, Deletions: 1},
+				{Path: "app.py", Insertions: 1, Deletions: 5},
+				{Path: "logo.png", IsBinary: true},
+			}},
+			{ChangedFiles: []*commit.ChangedFile{
+				{Path: "util.go", Insertions: 3, Deletions: 4},
+				{Path: "LICENSE", Insertions: 7},
+			}},
+			{ChangedFiles: []*commit.ChangedFile{}},
+		}
+		Expect(extractor.LanguageSummary(commits)).To(Equal(map[string]extractor.LanguageStat{
+			"Go":                      {Commits: 2, Insertions: 14, Deletions: 7},
+			"Python":                  {Commits: 1, Insertions: 1, Deletions: 5},
+			extractor.UnknownLanguage: {Commits: 2, Insertions: 7, Deletions: 0},
+		}))
+	})
+})
+
+var _ = Describe("LanguageCount", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should count the distinct languages of the commits", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"main.go":   "package main\n",
+			"util.go":   "package main\n",
+			"script.py": "print('hello')\n",
+			"index.js":  "console.log('hello')\n",
+			"README.md": "readme\n",
+			"Makefile":  "all:\n",
+		}})
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n\nfunc main() {}\n"}})
+
+		metadata, commits := extractRepo(repo, nil)
+		counts := map[string]int{}
+		for _, c := range commits {
+			counts[c.Subject] = c.LanguageCount
+		}
+		Expect(counts).To(Equal(map[string]int{"commit 1": 4, "commit 2": 1}))
+		Expect(metadata["maxLanguagesInCommit"]).To(BeEquivalentTo(4))
+	})
+
+	It("should count the languages of the extensions without the library detection", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"main.go":   "package main\n",
+			"script.py": "print('hello')\n",
+			"Makefile":  "all:\n",
+		}})
+
+		metadata, commits := extractRepo(repo, skipLibraries)
+		Expect(commits[0].LanguageCount).To(Equal(2))
+		Expect(metadata["maxLanguagesInCommit"]).To(BeEquivalentTo(2))
+	})
+})
+
+var _ = Describe("PrimaryLanguages", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should sort the languages of the user by lines", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"main.go":   "package main\n\nfunc main() {}\n",
+			"script.py": "import os\n",
+			"data.xyz":  strings.Repeat("unknown\n", 20),
+		}})
+		repo.commit(testCommit{Files: map[string]string{"script.py": "import os\nimport sys\n\nprint(os, sys)\n"}})
+		repo.commit(testCommit{
+			Email: "other@example.com",
+			Files: map[string]string{"index.js": strings.Repeat("console.log('other')\n", 20)},
+		})
+
+		var re *extractor.RepoExtractor
+		metadata, _ := extractRepo(repo, func(e *extractor.RepoExtractor) {
+			re = e
+			re.SkipLibraries = true
+			Expect(re.PrimaryLanguages()).To(BeEmpty())
+		})
+		Expect(re.PrimaryLanguages()).To(Equal([]string{"Python", "Go"}))
+		Expect(metadata["primaryLanguages"]).To(Equal([]interface{}{"Python", "Go"}))
+	})
+})
+
+var _ = Describe("StatsByAuthor", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should sum the commits and lines of every selected email", func() {
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n\nfunc main() {}\n", "util.go": "package main\n"}})
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		// Only adds
+		repo.commit(testCommit{Email: "work@example.com", Files: map[string]string{"app.py": "import os\nimport sys\n"}})
+		// Only deletes
+		repo.commit(testCommit{Email: "work@example.com", Deleted: []string{"util.go"}})
+		repo.commit(testCommit{Email: "other@example.com", Files: map[string]string{"other.go": "package other\n"}})
+
+		var re *extractor.RepoExtractor
+		metadata, _ := extractRepo(repo, func(e *extractor.RepoExtractor) {
+			re = e
+			re.SkipLibraries = true
+			re.UserEmails = []string{"test@example.com", "work@example.com"}
+			Expect(re.StatsByAuthor()).To(BeEmpty())
+		})
+		Expect(re.StatsByAuthor()).To(Equal(map[string]extractor.AuthorStats{
+			"test@example.com": {Commits: 2, Insertions: 4, Deletions: 2, Files: 2},
+			"work@example.com": {Commits: 2, Insertions: 2, Deletions: 1, Files: 2},
+		}))
+		Expect(metadata["authorStats"]).To(HaveKeyWithValue("work@example.com", map[string]interface{}{
+			"commits": 2.0, "insertions": 2.0, "deletions": 1.0, "files": 2.0,
+		}))
+	})
+})
+
+var _ = Describe("TimeZones", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		// Written in Budapest, rebased in New York
+		repo.commit(testCommit{
+			Date:          "2020-01-01T10:00:00+0100",
+			CommitterDate: "2020-01-05T09:00:00-0500",
+			Files:         map[string]string{"main.go": "package main\n"},
+		})
+		repo.commit(testCommit{
+			Date:          "2020-01-02T10:00:00+0100",
+			CommitterDate: "2020-01-05T09:01:00-0500",
+			Files:         map[string]string{"util.go": "package main\n"},
+		})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(source string) map[string]interface{} {
+		metadata, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.TimeZoneSource = source
+		})
+		// Both dates are recorded
+		Expect(commits[0].Date).To(Equal("2020-01-02 10:00:00 +0100"))
+		Expect(commits[0].CommitterDate).To(Equal("2020-01-05 09:01:00 -0500"))
+		return metadata
+	}
+
+	It("should count the time zones of the author dates by default", func() {
+		metadata := extract("")
+		Expect(metadata["timeZoneSource"]).To(Equal(extractor.DateSourceAuthor))
+		Expect(metadata["timeZones"]).To(Equal([]interface{}{
+			map[string]interface{}{"offset": "+0100", "commits": 2.0},
+		}))
+	})
+
+	It("should count the time zones of the committer dates", func() {
+		metadata := extract(extractor.DateSourceCommitter)
+		Expect(metadata["timeZoneSource"]).To(Equal(extractor.DateSourceCommitter))
+		Expect(metadata["timeZones"]).To(Equal([]interface{}{
+			map[string]interface{}{"offset": "-0500", "commits": 2.0},
+		}))
+	})
+
+	It("should fail for unknown sources", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, TimeZoneSource: "reviewer"}
+		Expect(re.Extract()).To(MatchError("unknown time zone source reviewer"))
+	})
+})
+
+var _ = Describe("PathWeights", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Message: "Core", Files: map[string]string{"src/core/engine.go": "package core\n\nfunc Run() {}\n"}})
+		repo.commit(testCommit{Message: "Other", Files: map[string]string{"src/cli/main.go": "package main\n\nfunc main() {}\n"}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(weights map[string]float64) (map[string]interface{}, map[string]float64) {
+		metadata, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.PathWeights = weights
+		})
+		scores := map[string]float64{}
+		for _, c := range commits {
+			scores[c.Subject] = c.WeightedScore
+		}
+		return metadata, scores
+	}
+
+	It("should weigh every line 1 by default", func() {
+		metadata, scores := extract(nil)
+		Expect(scores).To(Equal(map[string]float64{"Core": 3, "Other": 3}))
+		Expect(metadata["weightedScore"]).To(BeEquivalentTo(6))
+	})
+
+	It("should weigh the lines of the matching paths", func() {
+		metadata, scores := extract(map[string]float64{"core/": 2.5, "src/core/*.go": 3, "*.md": 0})
+		Expect(scores).To(Equal(map[string]float64{"Core": 9, "Other": 3}))
+		Expect(metadata["weightedScore"]).To(BeEquivalentTo(12))
+	})
+
+	It("should weigh the paths before the obfuscation", func() {
+		metadata, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.Obfuscate = true
+			re.PathWeights = map[string]float64{"core/": 3}
+		})
+		scores := []float64{}
+		for _, c := range commits {
+			scores = append(scores, c.WeightedScore)
+		}
+		Expect(scores).To(ConsistOf(9.0, 3.0))
+		Expect(metadata["weightedScore"]).To(BeEquivalentTo(12))
+	})
+
+	It("should fail for invalid patterns", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, PathWeights: map[string]float64{"[": 2}}
+		Expect(re.Extract()).To(MatchError(ContainSubstring("invalid path weight pattern [")))
+	})
+})
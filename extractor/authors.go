@@ -0,0 +1,94 @@
+package extractor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// authorOverride is the canonical identity of a raw email
+type authorOverride struct {
+	Email string
+	Name  string // Empty keeps the name of the commit
+}
+
+// readAuthorMapping reads the CSV mapping of AuthorMappingPath
+// Every row is "raw email,canonical email[,canonical name]". Rows starting with # and a header
+// without an email in the first column are skipped. Emails are compared case-insensitively.
+func readAuthorMapping(path string) (map[string]authorOverride, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read author mapping: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	mapping := map[string]authorOverride{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read author mapping: %w", err)
+		}
+		if line == 1 && !strings.Contains(record[0], "@") {
+			continue
+		}
+		if len(record) < 2 || record[0] == "" || record[1] == "" {
+			return nil, fmt.Errorf("invalid author mapping %s: row %d needs a raw and a canonical email", path, line)
+		}
+		override := authorOverride{Email: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			override.Name = strings.TrimSpace(record[2])
+		}
+		mapping[strings.ToLower(strings.TrimSpace(record[0]))] = override
+	}
+	return mapping, nil
+}
+
+// mapAuthors applies the mapping of AuthorMappingPath to the commits and returns it
+func (r *RepoExtractor) mapAuthors(commits []*commit.Commit) (map[string]authorOverride, error) {
+	if r.AuthorMappingPath == "" {
+		return nil, nil
+	}
+	mapping, err := readAuthorMapping(r.AuthorMappingPath)
+	if err != nil {
+		return nil, err
+	}
+	applyAuthorMapping(commits, mapping)
+	return mapping, nil
+}
+
+// applyAuthorMapping replaces the authors of the commits with their canonical identities
+func applyAuthorMapping(commits []*commit.Commit, mapping map[string]authorOverride) {
+	for _, c := range commits {
+		override, ok := mapping[strings.ToLower(c.AuthorEmail)]
+		if !ok {
+			continue
+		}
+		c.AuthorEmail = override.Email
+		c.AuthorDomain = emailDomain(override.Email)
+		if override.Name != "" {
+			c.AuthorName = override.Name
+		}
+	}
+}
+
+// mapEmails returns the canonical emails of the given emails
+func mapEmails(emails []string, mapping map[string]authorOverride) []string {
+	mapped := make([]string, 0, len(emails))
+	for _, email := range emails {
+		if override, ok := mapping[strings.ToLower(email)]; ok {
+			mapped = append(mapped, override.Email)
+		}
+	}
+	return mapped
+}
@@ -0,0 +1,61 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Author mapping", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Name: "Person", Email: "person@work.com", Files: map[string]string{"a.go": "package a\n"}})
+		repo.commit(testCommit{Name: "Pers", Email: "alt@personal.com", Files: map[string]string{"b.go": "package b\n"}})
+		repo.commit(testCommit{Name: "P", Email: "alt2@personal.com", Files: map[string]string{"c.go": "package c\n"}})
+		repo.commit(testCommit{Name: "Other", Email: "other@example.com", Files: map[string]string{"d.go": "package d\n"}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(userEmails []string) []*commit.Commit {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.AuthorMappingPath = "./fixtures/authors.csv"
+			re.UserEmails = userEmails
+		})
+		return commits
+	}
+
+	It("should attribute the mapped emails to the canonical identity", func() {
+		commits := extract([]string{"person@work.com"})
+		Expect(commits).To(HaveLen(3))
+		names := []string{}
+		for _, c := range commits {
+			Expect(c.AuthorEmail).To(Equal("person@work.com"))
+			names = append(names, c.AuthorName)
+		}
+		Expect(names).To(ConsistOf("Person", "Person", "P"))
+	})
+
+	It("should map the given emails too", func() {
+		Expect(extract([]string{"alt@personal.com"})).To(HaveLen(3))
+	})
+
+	It("should fail for a missing mapping", func() {
+		re := extractor.RepoExtractor{
+			RepoPath:          repo.Path,
+			Headless:          true,
+			AuthorMappingPath: "./fixtures/missing.csv",
+			UserEmails:        []string{"person@work.com"},
+		}
+		err := re.Extract()
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(HavePrefix("step analyseCommits: cannot read author mapping"))
+	})
+})
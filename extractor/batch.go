@@ -0,0 +1,77 @@
+package extractor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// BatchError is returned by BatchExtract if some of the repos failed
+type BatchError struct {
+	Paths  []string         // The failed repos in the order of the extraction
+	Errors map[string]error // By path
+	Total  int              // Number of repos, including the successful ones
+}
+
+func (e *BatchError) Error() string {
+	failures := make([]string, 0, len(e.Paths))
+	for _, path := range e.Paths {
+		failures = append(failures, fmt.Sprintf("%s: %s", path, e.Errors[path]))
+	}
+	return fmt.Sprintf("%d of %d repos failed: %s", len(e.Paths), e.Total, strings.Join(failures, "; "))
+}
+
+// BatchExtract extracts every repo in paths with the settings of r
+// Every repo gets its own output, <OutputPath>_<name of the repo directory>, and its own cursor.
+// The emails chosen for a repo are used for the next ones, so the user is asked once.
+// A failing repo doesn't stop the others, the failures are returned as a *BatchError.
+func (r *RepoExtractor) BatchExtract(paths []string) error {
+	// The outputs are derived from the configured OutputPath
+	err := r.applyConfig()
+	if err != nil {
+		return err
+	}
+	batchErr := &BatchError{Errors: map[string]error{}, Total: len(paths)}
+	emails := r.UserEmails
+	names := map[string]int{}
+	for i, path := range paths {
+		r.logf("Extracting %s (%d/%d)", path, i+1, len(paths))
+		repoExtractor := *r
+		repoExtractor.RepoPath = path
+		repoExtractor.UserEmails = emails
+		suffix := batchOutputName(path, names)
+		repoExtractor.OutputPath = r.outputPath() + "_" + suffix
+		if r.CursorPath != "" {
+			repoExtractor.CursorPath = r.CursorPath + "_" + suffix
+		}
+
+		err = repoExtractor.Extract()
+		if err != nil {
+			r.logf("Cannot extract %s. Error: %s", path, err.Error())
+			batchErr.Paths = append(batchErr.Paths, path)
+			batchErr.Errors[path] = err
+			continue
+		}
+		if len(emails) == 0 && repoExtractor.repo != nil {
+			emails = repoExtractor.repo.Emails
+		}
+	}
+	if len(batchErr.Paths) > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+// batchOutputName returns the name of the output of the repo in path
+// Repos in directories with the same name are numbered, names counts them.
+func batchOutputName(path string, names map[string]int) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	name := filepath.Base(path)
+	names[name]++
+	if names[name] > 1 {
+		return fmt.Sprintf("%s_%d", name, names[name])
+	}
+	return name
+}
@@ -0,0 +1,77 @@
+package extractor_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("BatchExtract", func() {
+	var first, second *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		first = newTestRepo()
+		first.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		second = newTestRepo()
+		second.commit(testCommit{Files: map[string]string{"app.py": "import os\n"}})
+		second.commit(testCommit{Files: map[string]string{"lib.py": "import sys\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		first.remove()
+		second.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	newExtractor := func() *extractor.RepoExtractor {
+		return &extractor.RepoExtractor{
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			Headless:      true,
+			SkipLibraries: true,
+			UserEmails:    []string{"test@example.com"},
+		}
+	}
+
+	outputOf := func(repo *testRepo) string {
+		return filepath.Join(outputDir, "repo_data_"+filepath.Base(repo.Path)+"_v2.json.zip")
+	}
+
+	It("should write the output of every repo", func() {
+		Expect(newExtractor().BatchExtract([]string{first.Path, second.Path})).Should(Succeed())
+		_, commits := readOutput(outputOf(first))
+		Expect(commits).To(HaveLen(1))
+		_, commits = readOutput(outputOf(second))
+		Expect(commits).To(HaveLen(2))
+	})
+
+	It("should continue after a failing repo", func() {
+		missing := filepath.Join(outputDir, "missing")
+		err := newExtractor().BatchExtract([]string{first.Path, missing, second.Path})
+		var batchErr *extractor.BatchError
+		Expect(errors.As(err, &batchErr)).Should(BeTrue())
+		Expect(batchErr.Paths).To(Equal([]string{missing}))
+		Expect(batchErr.Total).To(Equal(3))
+		Expect(err.Error()).Should(HavePrefix("1 of 3 repos failed: " + missing + ": step "))
+
+		_, commits := readOutput(outputOf(second))
+		Expect(commits).To(HaveLen(2))
+	})
+
+	It("should number the repos with the same directory name", func() {
+		Expect(newExtractor().BatchExtract([]string{first.Path, first.Path})).Should(Succeed())
+		for _, name := range []string{filepath.Base(first.Path), filepath.Base(first.Path) + "_2"} {
+			_, err := os.Stat(filepath.Join(outputDir, "repo_data_"+name+"_v2.json.zip"))
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	})
+})
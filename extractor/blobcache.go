@@ -0,0 +1,55 @@
+package extractor
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// blobCacheSize is the number of files whose detected libraries are kept during an extraction
+// The cache is created for every extraction, it is replaced by the benchmarks.
+var blobCacheSize = 10000
+
+// detectedFile is the outcome of the library detection of a file
+type detectedFile struct {
+	language  string
+	libraries map[string][]string // By language
+}
+
+// libraryCache keeps the detected libraries of the files by blob, so identical files
+// like reverted or cherry-picked changes are analysed once. It is safe for concurrent use.
+// When it is full it starts over, so the memory stays bounded.
+type libraryCache struct {
+	mu         sync.Mutex
+	entries    map[string]detectedFile
+	maxEntries int
+}
+
+// newLibraryCache returns an empty cache, it keeps nothing if maxEntries isn't positive
+func newLibraryCache(maxEntries int) *libraryCache {
+	return &libraryCache{entries: map[string]detectedFile{}, maxEntries: maxEntries}
+}
+
+// libraryCacheKey identifies the file by its blob and its name
+// The detection depends on the name too, e.g. on the extension or Package.swift.
+func libraryCacheKey(oid, path string) string {
+	return oid + ":" + filepath.Base(path)
+}
+
+func (c *libraryCache) get(key string) (detectedFile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	detected, ok := c.entries[key]
+	return detected, ok
+}
+
+func (c *libraryCache) add(key string, detected detectedFile) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		c.entries = map[string]detectedFile{}
+	}
+	c.entries[key] = detected
+}
@@ -0,0 +1,57 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// blobHashPathsPerCommand limits the number of paths given to a git ls-tree
+const blobHashPathsPerCommand = 500
+
+// setBlobHashes records the blob hash of every changed file, deleted files have none
+func (r *RepoExtractor) setBlobHashes(commits []*commit.Commit) error {
+	for _, c := range commits {
+		for start := 0; start < len(c.ChangedFiles); start += blobHashPathsPerCommand {
+			end := start + blobHashPathsPerCommand
+			if end > len(c.ChangedFiles) {
+				end = len(c.ChangedFiles)
+			}
+			hashes, err := r.blobHashes(c.Hash, c.ChangedFiles[start:end])
+			if err != nil {
+				return err
+			}
+			for _, f := range c.ChangedFiles[start:end] {
+				f.BlobHash = hashes[f.Path]
+			}
+		}
+	}
+	return nil
+}
+
+// blobHashes returns the blob hashes of the files in the commit by path
+func (r *RepoExtractor) blobHashes(hash string, files []*commit.ChangedFile) (map[string]string, error) {
+	args := []string{"ls-tree", "-z", hash, "--"}
+	for _, f := range files {
+		args = append(args, f.Path)
+	}
+	out, err := r.runGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the files of %s: %w", hash, err)
+	}
+	hashes := map[string]string{}
+	// Entries look like "<mode> <type> <hash><TAB><path>"
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		tab := bytes.IndexByte(entry, '\t')
+		if tab == -1 {
+			continue
+		}
+		fields := bytes.Fields(entry[:tab])
+		if len(fields) != 3 || string(fields[1]) != "blob" {
+			continue
+		}
+		hashes[string(entry[tab+1:])] = string(fields[2])
+	}
+	return hashes, nil
+}
@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"strings"
+)
+
+// defaultBranchCandidates are probed if the remote doesn't tell its default branch
+var defaultBranchCandidates = []string{"main", "master"}
+
+// initDefaultBranch finds the default branch of the repo
+// It is the HEAD of the remote, otherwise main or master, otherwise the branch checked out.
+// Without any of them, e.g. on a detached HEAD, the default branch stays empty and HEAD is used.
+func (r *RepoExtractor) initDefaultBranch() {
+	r.defaultBranch, r.defaultRef = "", ""
+	remote := r.RemoteName
+	if remote == "" {
+		remote = defaultRemoteName
+	}
+	// Its output looks like origin/trunk
+	out, err := r.runGit("symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err == nil {
+		ref := strings.TrimSpace(string(out))
+		r.defaultBranch, r.defaultRef = strings.TrimPrefix(ref, remote+"/"), "refs/remotes/"+ref
+		// The local branch has the commits of the user which aren't pushed yet
+		if r.verifyCommit("refs/heads/"+r.defaultBranch) == nil {
+			r.defaultRef = "refs/heads/" + r.defaultBranch
+		}
+		return
+	}
+	for _, branch := range defaultBranchCandidates {
+		if r.verifyCommit("refs/heads/"+branch) == nil {
+			r.defaultBranch, r.defaultRef = branch, "refs/heads/"+branch
+			return
+		}
+	}
+	out, err = r.runGit("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err == nil {
+		branch := strings.TrimSpace(string(out))
+		if r.verifyCommit("refs/heads/"+branch) == nil {
+			r.defaultBranch, r.defaultRef = branch, "refs/heads/"+branch
+		}
+	}
+}
+
+// headRef returns the ref used where no ref is given, the default branch or HEAD
+func (r *RepoExtractor) headRef() string {
+	if r.defaultRef != "" {
+		return r.defaultRef
+	}
+	return "HEAD"
+}
@@ -0,0 +1,85 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Default branch", func() {
+	var upstream, clone *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		upstream = newTestRepo()
+		upstream.git("checkout", "--quiet", "-b", "trunk")
+		upstream.commit(testCommit{Files: map[string]string{"a.go": "package a\n"}})
+		upstream.commit(testCommit{Files: map[string]string{"b.go": "package b\n"}})
+
+		dir, err := ioutil.TempDir("", "repo_info_extractor_clone")
+		Expect(err).ShouldNot(HaveOccurred())
+		clone = &testRepo{Path: dir}
+		upstream.git("clone", "--quiet", upstream.Path, clone.Path)
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		upstream.remove()
+		clone.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	extract := func(repo *testRepo, incremental bool) map[string]interface{} {
+		// The output stays next to the cursor
+		metadata, _ := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.OutputPath = filepath.Join(outputDir, "repo_data")
+			re.SkipLibraries = true
+			re.Incremental = incremental
+		})
+		return metadata
+	}
+
+	It("should use the HEAD of the remote on a detached HEAD", func() {
+		clone.git("checkout", "--quiet", "--detach", "HEAD~1")
+		metadata := extract(clone, true)
+		Expect(metadata["defaultBranch"]).To(Equal("trunk"))
+
+		// The incremental extraction reads up to the default branch, not HEAD
+		cursor, err := ioutil.ReadFile(filepath.Join(outputDir, "repo_data.repo.cursor"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(strings.TrimSpace(string(cursor))).To(Equal(clone.git("rev-parse", "origin/trunk")))
+	})
+
+	It("should prefer the local default branch to the one of the remote", func() {
+		clone.commit(testCommit{Files: map[string]string{"c.go": "package c\n"}})
+		clone.git("checkout", "--quiet", "--detach", "HEAD~1")
+		extract(clone, true)
+
+		// The commit which isn't pushed yet is read as well
+		cursor, err := ioutil.ReadFile(filepath.Join(outputDir, "repo_data.repo.cursor"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(strings.TrimSpace(string(cursor))).To(Equal(clone.git("rev-parse", "trunk")))
+	})
+
+	It("should fall back to the checked out branch without a remote", func() {
+		Expect(extract(upstream, false)["defaultBranch"]).To(Equal("trunk"))
+	})
+
+	It("should prefer main or master to the checked out branch", func() {
+		upstream.git("branch", "master")
+		upstream.git("checkout", "--quiet", "-b", "feature")
+		Expect(extract(upstream, false)["defaultBranch"]).To(Equal("master"))
+	})
+
+	It("should leave the default branch empty if it is unknown", func() {
+		upstream.git("checkout", "--quiet", "--detach")
+		Expect(extract(upstream, false)["defaultBranch"]).To(Equal(""))
+	})
+})
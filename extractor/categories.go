@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"path"
+	"strings"
+)
+
+// categoryDoc is the category of documentation files
+const categoryDoc = "doc"
+
+var docExtensions = map[string]bool{
+	"md":       true,
+	"markdown": true,
+	"rst":      true,
+	"adoc":     true,
+	"asciidoc": true,
+	"txt":      true,
+}
+
+// Files which are documentation even without an extension
+var docFileNames = map[string]bool{
+	"readme":       true,
+	"changelog":    true,
+	"changes":      true,
+	"license":      true,
+	"contributing": true,
+	"authors":      true,
+	"notice":       true,
+}
+
+var docDirectories = map[string]bool{
+	"doc":  true,
+	"docs": true,
+}
+
+// fileCategory returns the category of the file in filePath or "" if it has no special category
+func fileCategory(filePath string) string {
+	dirs := strings.Split(path.Dir(filePath), "/")
+	for _, dir := range dirs {
+		if docDirectories[strings.ToLower(dir)] {
+			return categoryDoc
+		}
+	}
+
+	name := strings.ToLower(path.Base(filePath))
+	extension := strings.TrimPrefix(path.Ext(name), ".")
+	if docExtensions[extension] || docFileNames[strings.TrimSuffix(name, path.Ext(name))] {
+		return categoryDoc
+	}
+	return ""
+}
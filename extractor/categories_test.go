@@ -0,0 +1,39 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("SkipDocOnlyCommits", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n", "README.md": "readme\n"}})
+		repo.commit(testCommit{Files: map[string]string{"README.md": "new readme\n"}})
+		repo.commit(testCommit{Files: map[string]string{"docs/usage": "usage\n", "LICENSE": "MIT\n"}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(skipDocOnlyCommits bool) int {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.SkipDocOnlyCommits = skipDocOnlyCommits
+		})
+		return len(commits)
+	}
+
+	It("should drop the documentation only commits", func() {
+		Expect(extract(true)).To(Equal(1))
+	})
+
+	It("should keep every commit by default", func() {
+		Expect(extract(false)).To(Equal(3))
+	})
+})
@@ -0,0 +1,156 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errBlobTooLarge is returned by blobReader.read for files larger than maxSize
+var errBlobTooLarge = errors.New("the file is too large")
+
+// errBlobTimeout is returned by blobReader.read when git cat-file was killed for taking longer than timeout
+var errBlobTimeout = errors.New("git cat-file timed out")
+
+// blobReader reads file contents through a long running git cat-file --batch
+// It saves starting a git show for every file. It is not safe for concurrent use.
+type blobReader struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	stderr  *lockedBuffer
+	maxSize int           // Larger files are discarded without keeping them in memory, zero keeps every file
+	timeout time.Duration // Reading a file takes at most this long, zero is no limit
+	kill    func()        // Kills git cat-file when a read times out, like the cancel of its context
+}
+
+// lockedBuffer is a buffer which can be read while os/exec copies the stderr of git cat-file into it
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.buffer.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.buffer.String()
+}
+
+// newBlobReader starts cmd, a git cat-file --batch command
+func newBlobReader(cmd *exec.Cmd) (*blobReader, error) {
+	stderr := &lockedBuffer{}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("cannot start git cat-file: %w", err)
+	}
+	return &blobReader{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		stderr: stderr,
+	}, nil
+}
+
+// read returns the contents of the file in the commit
+// The second return value is false if the file doesn't exist in the commit, e.g. because it was deleted.
+// Files larger than maxSize return errBlobTooLarge, the reader can still be used.
+func (b *blobReader) read(hash, path string) ([]byte, bool, error) {
+	_, contents, found, err := b.readBlob(hash, path, b.maxSize)
+	return contents, found, err
+}
+
+// readBlob is read returning the object id of the blob too, with the size limit maxSize instead of b.maxSize
+// If it takes longer than timeout git cat-file is killed and it returns errBlobTimeout,
+// the reader can't be used anymore.
+func (b *blobReader) readBlob(hash, path string, maxSize int) (string, []byte, bool, error) {
+	if b.timeout <= 0 || b.kill == nil {
+		return b.request(hash, path, maxSize)
+	}
+	timer := time.AfterFunc(b.timeout, b.kill)
+	oid, contents, found, err := b.request(hash, path, maxSize)
+	if !timer.Stop() {
+		return "", nil, false, errBlobTimeout
+	}
+	return oid, contents, found, err
+}
+
+// request asks git cat-file for the file in the commit and reads the answer
+func (b *blobReader) request(hash, path string, maxSize int) (string, []byte, bool, error) {
+	// The requests are line based, such paths can't be asked for
+	if strings.ContainsAny(path, "\n\r") {
+		return "", nil, false, nil
+	}
+	_, err := fmt.Fprintf(b.stdin, "%s:%s\n", hash, path)
+	if err != nil {
+		return "", nil, false, b.failed(err)
+	}
+
+	// The header is "<oid> <type> <size>" or "<object> missing"
+	header, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return "", nil, false, b.failed(err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		// missing or ambiguous
+		return "", nil, false, nil
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", nil, false, fmt.Errorf("unexpected git cat-file header %q", header)
+	}
+	if maxSize > 0 && size > maxSize {
+		_, err = io.CopyN(ioutil.Discard, b.stdout, int64(size)+1)
+		if err != nil {
+			return "", nil, false, b.failed(err)
+		}
+		return "", nil, false, errBlobTooLarge
+	}
+	contents := make([]byte, size+1) // The contents end with a newline
+	_, err = io.ReadFull(b.stdout, contents)
+	if err != nil {
+		return "", nil, false, b.failed(err)
+	}
+	if fields[1] != "blob" {
+		return "", nil, false, nil
+	}
+	return fields[0], contents[:size], true, nil
+}
+
+// failed adds the output of git to the error of a broken stream
+func (b *blobReader) failed(err error) error {
+	return fmt.Errorf("git cat-file failed: %w: %s", err, strings.TrimSpace(b.stderr.String()))
+}
+
+// Close stops git cat-file
+func (b *blobReader) Close() error {
+	b.stdin.Close()
+	err := b.cmd.Wait()
+	if b.kill != nil {
+		b.kill()
+	}
+	return err
+}
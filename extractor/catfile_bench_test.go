@@ -0,0 +1,145 @@
+package extractor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const benchmarkFiles = 100
+
+// newBenchmarkRepo creates a repo with one commit of benchmarkFiles files
+func newBenchmarkRepo(b *testing.B) (string, string) {
+	dir, err := ioutil.TempDir("", "repo_info_extractor_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < benchmarkFiles; i++ {
+		err = ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.go", i)), []byte("package main\n"), 0644)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			b.Fatal(string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	git("add", "--all")
+	git("-c", "user.name=Bench", "-c", "user.email=bench@example.com", "commit", "--quiet", "-m", "bench")
+	return dir, git("rev-parse", "HEAD")
+}
+
+func BenchmarkBlobReader(b *testing.B) {
+	dir, hash := newBenchmarkRepo(b)
+	defer os.RemoveAll(dir)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		cmd := exec.Command("git", "cat-file", "--batch")
+		cmd.Dir = dir
+		blobs, err := newBlobReader(cmd)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < benchmarkFiles; i++ {
+			_, _, err := blobs.read(hash, fmt.Sprintf("file%d.go", i))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		blobs.Close()
+	}
+}
+
+// BenchmarkGitShow is the former way of reading the files, a git show for each of them
+func BenchmarkGitShow(b *testing.B) {
+	dir, hash := newBenchmarkRepo(b)
+	defer os.RemoveAll(dir)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < benchmarkFiles; i++ {
+			cmd := exec.Command("git", "--no-pager", "show", fmt.Sprintf("%s:file%d.go", hash, i))
+			cmd.Dir = dir
+			_, err := cmd.CombinedOutput()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// newChurnRepo creates a repo whose commits flip the files between two contents
+func newChurnRepo(b *testing.B, commits, files int) string {
+	dir, err := ioutil.TempDir("", "repo_info_extractor_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			b.Fatal(string(out))
+		}
+	}
+	git("init", "--quiet")
+	contents := []string{
+		strings.Repeat("import numpy\nfrom requests import get\n", 500),
+		strings.Repeat("import pandas\nfrom flask import Flask\n", 500),
+	}
+	for c := 0; c < commits; c++ {
+		for i := 0; i < files; i++ {
+			err = ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.py", i)), []byte(contents[c%2]), 0644)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		git("add", "--all")
+		git("-c", "user.name=Bench", "-c", "user.email=bench@example.com", "commit", "--quiet", "-m", "bench")
+	}
+	return dir
+}
+
+func benchmarkLibraryDetection(b *testing.B, cacheSize int) {
+	dir := newChurnRepo(b, 20, 20)
+	defer os.RemoveAll(dir)
+	defer func(original int) { blobCacheSize = original }(blobCacheSize)
+	blobCacheSize = cacheSize
+	defer func(original Logger) { defaultLogger = original }(defaultLogger)
+	defaultLogger = log.New(ioutil.Discard, "", 0)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		r := RepoExtractor{
+			RepoPath:    dir,
+			OutputPath:  filepath.Join(dir, "repo_data"),
+			Headless:    true,
+			UserEmails:  []string{"bench@example.com"},
+			Concurrency: 1,
+		}
+		err := r.Extract()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLibraryDetectionCached(b *testing.B) {
+	benchmarkLibraryDetection(b, blobCacheSize)
+}
+
+// BenchmarkLibraryDetectionUncached analyses every file of every commit
+func BenchmarkLibraryDetectionUncached(b *testing.B) {
+	benchmarkLibraryDetection(b, 0)
+}
@@ -0,0 +1,62 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Blob reader", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should read the files of the commits", func() {
+		first := repo.commit(testCommit{Files: map[string]string{
+			"main.go":        "package main\n",
+			"no newline.txt": "last line",
+			"binary.bin":     "\x00\x01\n\x02\n",
+			"empty":          "",
+		}})
+		second := repo.commit(testCommit{
+			Files:   map[string]string{"main.go": "package main\n\nfunc main() {}\n"},
+			Deleted: []string{"binary.bin"},
+		})
+
+		blobs, err := extractor.NewBlobReader("git", repo.Path)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer blobs.Close()
+
+		expectFile := func(hash, path, contents string) {
+			read, found, err := blobs.Read(hash, path)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(string(read)).To(Equal(contents))
+		}
+		expectMissing := func(hash, path string) {
+			_, found, err := blobs.Read(hash, path)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		}
+
+		expectFile(first, "main.go", "package main\n")
+		expectFile(first, "no newline.txt", "last line")
+		expectFile(first, "binary.bin", "\x00\x01\n\x02\n")
+		expectFile(first, "empty", "")
+		expectFile(second, "main.go", "package main\n\nfunc main() {}\n")
+		expectMissing(second, "binary.bin")
+		expectMissing(second, "missing.go")
+		expectMissing(second, "new\nline")
+		// A tree is no file
+		expectMissing(second, "")
+		expectFile(first, "main.go", "package main\n")
+		Expect(blobs.Close()).Should(Succeed())
+	})
+})
@@ -0,0 +1,45 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// DefaultCloneDepth is the number of commits fetched when RemoteURL is cloned without FullClone
+const DefaultCloneDepth = 1000
+
+// cloneRemote clones RemoteURL into a temporary directory and sets RepoPath to it
+// The returned function removes the clone.
+func (r *RepoExtractor) cloneRemote() (func(), error) {
+	dir, err := ioutil.TempDir("", "repo_info_extractor_clone")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		os.RemoveAll(dir)
+		r.RepoPath = ""
+	}
+
+	args := []string{"clone", "--quiet", "--no-tags"}
+	if !r.FullClone {
+		depth := r.CloneDepth
+		if depth <= 0 {
+			depth = DefaultCloneDepth
+		}
+		args = append(args, fmt.Sprintf("--depth=%d", depth))
+	}
+	args = append(args, "--", r.RemoteURL, dir)
+
+	r.logf("Cloning %s", r.RemoteURL)
+	// The clone doesn't run in the repo, it creates it
+	_, err = r.runner().Run(context.Background(), "", args...)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("cannot clone %s: %w", r.RemoteURL, err)
+	}
+
+	r.RepoPath = dir
+	return cleanup, nil
+}
@@ -0,0 +1,74 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("RemoteURL", func() {
+	var repo *testRepo
+	var bareRepo string
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		for _, contents := range []string{"a\n", "b\n", "c\n"} {
+			repo.commit(testCommit{Files: map[string]string{"main.go": contents}})
+		}
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		bareRepo = filepath.Join(outputDir, "remote.git")
+		repo.git("clone", "--quiet", "--bare", repo.Path, bareRepo)
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	extract := func(fullClone bool, cloneDepth int) int {
+		var re *extractor.RepoExtractor
+		metadata, commits := extractRepo(repo, func(e *extractor.RepoExtractor) {
+			re = e
+			re.RepoPath = ""
+			re.RemoteURL = "file://" + bareRepo
+			re.FullClone = fullClone
+			re.CloneDepth = cloneDepth
+			re.SkipLibraries = true
+		})
+		Expect(re.RepoPath).To(BeEmpty())
+		Expect(metadata["repo"]).To(HaveSuffix("/remote"))
+		return len(commits)
+	}
+
+	It("should clone the full history", func() {
+		Expect(extract(true, 0)).To(Equal(3))
+	})
+
+	It("should make a shallow clone", func() {
+		Expect(extract(false, 1)).To(Equal(1))
+	})
+
+	It("should remove the clone", func() {
+		before, err := filepath.Glob(filepath.Join(os.TempDir(), "repo_info_extractor_clone*"))
+		Expect(err).ShouldNot(HaveOccurred())
+		extract(false, 0)
+		after, err := filepath.Glob(filepath.Join(os.TempDir(), "repo_info_extractor_clone*"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(after).To(HaveLen(len(before)))
+	})
+
+	It("should fail for an invalid remote", func() {
+		re := extractor.RepoExtractor{RemoteURL: "file://" + filepath.Join(outputDir, "missing.git")}
+		err := re.Extract()
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(HavePrefix("step cloneRemote: cannot clone file://"))
+	})
+})
@@ -0,0 +1,79 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Config holds the settings read from the file at ConfigPath
+// The keys are the camel case names of the RepoExtractor fields,
+// e.g. {"userEmails": ["me@example.com"], "concurrency": 4, "sinceDate": "2020-01-01"}
+type Config struct {
+	UserEmails      []string `json:"userEmails"`
+	Concurrency     int      `json:"concurrency"`
+	ExcludePatterns []string `json:"excludePatterns"`
+	SinceDate       string   `json:"sinceDate"`
+	OutputPath      string   `json:"outputPath"`
+	OutputLayout    string   `json:"outputLayout"`
+	OutputFormat    string   `json:"outputFormat"`
+	KeepRawData     bool     `json:"keepRawData"`
+	Deterministic   bool     `json:"deterministic"`
+	SkipLibraries   bool     `json:"skipLibraries"`
+}
+
+// LoadConfig reads the JSON config file at path
+// Unknown keys are errors, so typos don't go unnoticed.
+func LoadConfig(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the config %s: %w", path, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	config := &Config{}
+	err = decoder.Decode(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// applyConfig fills the unset fields from the config at ConfigPath
+// Fields which are already set win over the config. The booleans can only be turned on by the config.
+func (r *RepoExtractor) applyConfig() error {
+	if r.ConfigPath == "" {
+		return nil
+	}
+	config, err := LoadConfig(r.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if len(r.UserEmails) == 0 {
+		r.UserEmails = config.UserEmails
+	}
+	if r.Concurrency == 0 {
+		r.Concurrency = config.Concurrency
+	}
+	// An empty slice turns off the exclusion, only nil is unset
+	if r.ExcludePatterns == nil {
+		r.ExcludePatterns = config.ExcludePatterns
+	}
+	if r.SinceDate == "" {
+		r.SinceDate = config.SinceDate
+	}
+	if r.OutputPath == "" {
+		r.OutputPath = config.OutputPath
+	}
+	if r.OutputLayout == "" {
+		r.OutputLayout = config.OutputLayout
+	}
+	if r.OutputFormat == "" {
+		r.OutputFormat = config.OutputFormat
+	}
+	r.KeepRawData = r.KeepRawData || config.KeepRawData
+	r.Deterministic = r.Deterministic || config.Deterministic
+	r.SkipLibraries = r.SkipLibraries || config.SkipLibraries
+	return nil
+}
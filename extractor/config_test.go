@@ -0,0 +1,108 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Config", func() {
+	var repo *testRepo
+	var outputDir, configPath string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		for _, c := range []testCommit{
+			{Email: "me@example.com", Date: "2019-06-01T10:00:00+0000", Files: map[string]string{"old.go": "package main\n"}},
+			{Email: "me@example.com", Date: "2020-06-01T10:00:00+0000", Files: map[string]string{"main.go": "package main\n", "docs/a.md": "a\n"}},
+			{Email: "other@example.com", Date: "2020-07-01T10:00:00+0000", Files: map[string]string{"other.go": "package main\n"}},
+		} {
+			// git log --since looks at the committer dates
+			c.CommitterDate = c.Date
+			repo.commit(c)
+		}
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		configPath = filepath.Join(outputDir, "config.json")
+		config := `{
+			"userEmails": ["me@example.com"],
+			"concurrency": 2,
+			"excludePatterns": ["docs"],
+			"sinceDate": "2020-01-01",
+			"outputPath": "` + filepath.Join(outputDir, "from_config") + `",
+			"outputFormat": "jsonl",
+			"skipLibraries": true
+		}`
+		Expect(ioutil.WriteFile(configPath, []byte(config), 0644)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	It("should load the config", func() {
+		config, err := extractor.LoadConfig(configPath)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config).To(Equal(&extractor.Config{
+			UserEmails:      []string{"me@example.com"},
+			Concurrency:     2,
+			ExcludePatterns: []string{"docs"},
+			SinceDate:       "2020-01-01",
+			OutputPath:      filepath.Join(outputDir, "from_config"),
+			OutputFormat:    extractor.FormatJSONL,
+			SkipLibraries:   true,
+		}))
+	})
+
+	It("should extract with the settings of the config", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, ConfigPath: configPath}
+		Expect(re.Extract()).Should(Succeed())
+		Expect(re.Concurrency).To(Equal(2))
+		Expect(re.SkipLibraries).To(BeTrue())
+
+		file, err := os.Open(filepath.Join(outputDir, "from_config_v2.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer file.Close()
+		metadata, commits := parseOutput(file)
+		Expect(metadata["emails"]).To(ConsistOf("me@example.com"))
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].ChangedFiles).To(HaveLen(1))
+		Expect(commits[0].ChangedFiles[0].Path).To(Equal("main.go"))
+	})
+
+	It("should prefer the fields to the config", func() {
+		re := extractor.RepoExtractor{
+			RepoPath:     repo.Path,
+			Headless:     true,
+			ConfigPath:   configPath,
+			UserEmails:   []string{"other@example.com"},
+			OutputPath:   filepath.Join(outputDir, "from_field"),
+			OutputFormat: extractor.FormatZip,
+		}
+		Expect(re.Extract()).Should(Succeed())
+		Expect(re.SinceDate).To(Equal("2020-01-01"))
+
+		metadata, commits := readOutput(filepath.Join(outputDir, "from_field_v2.json.zip"))
+		Expect(metadata["emails"]).To(ConsistOf("other@example.com"))
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].ChangedFiles[0].Path).To(Equal("other.go"))
+	})
+
+	It("should fail for unknown keys", func() {
+		Expect(ioutil.WriteFile(configPath, []byte(`{"userEmail": "me@example.com"}`), 0644)).Should(Succeed())
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, ConfigPath: configPath}
+		Expect(re.Extract()).To(MatchError(ContainSubstring("cannot parse the config")))
+	})
+
+	It("should fail for a missing config", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, ConfigPath: filepath.Join(outputDir, "missing.json")}
+		Expect(re.Extract()).To(MatchError(ContainSubstring("cannot read the config")))
+	})
+})
@@ -0,0 +1,170 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// cursorPath returns the file keeping the newest commit of the last incremental extraction
+func (r *RepoExtractor) cursorPath() string {
+	if r.CursorPath != "" {
+		return r.CursorPath
+	}
+	return r.outputPath() + ".repo.cursor"
+}
+
+// cursorEnd returns the revision read up to by the incremental extraction
+func (r *RepoExtractor) cursorEnd() string {
+	if r.Ref != "" {
+		return r.Ref
+	}
+	return r.headRef()
+}
+
+// resolveCommit returns the hash of the commit rev points to
+func (r *RepoExtractor) resolveCommit(rev string) (string, error) {
+	out, err := r.runGit("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid commit", rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// loadCursor reads the cursor of the last incremental extraction
+// It remembers the commit to save once the extraction succeeds. If the saved commit
+// doesn't exist anymore, e.g. after a force push, every commit is read again.
+func (r *RepoExtractor) loadCursor() error {
+	r.cursor, r.previous = "", nil
+	if strings.Contains(r.Ref, "..") {
+		return fmt.Errorf("incremental extraction needs a single ref, got the range %s", r.Ref)
+	}
+	newest, err := r.resolveCommit(r.cursorEnd())
+	if err != nil {
+		return fmt.Errorf("cannot resolve the end of the incremental extraction: %w", err)
+	}
+	r.newestCommit = newest
+
+	content, err := ioutil.ReadFile(r.cursorPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the cursor %s: %w", r.cursorPath(), err)
+	}
+	cursor := strings.TrimSpace(string(content))
+	err = r.verifyCommit(cursor)
+	if err != nil {
+		r.logf("The cursor %s is not a commit of the repo anymore, reading every commit", cursor)
+		return nil
+	}
+	previous, err := readRepoData(r.cursorDataPath())
+	if os.IsNotExist(err) {
+		r.logf("The results of the cursor %s are missing, reading every commit", cursor)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the results of the cursor %s: %w", r.cursorDataPath(), err)
+	}
+	r.cursor = cursor
+	r.previous = previous
+	return nil
+}
+
+// cursorDataPath returns the file keeping the results of the incremental extractions so far
+func (r *RepoExtractor) cursorDataPath() string {
+	return r.cursorPath() + ".data"
+}
+
+// readRepoData reads the metadata line and the commit lines written by writeRepoDataTo
+func readRepoData(path string) (*Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := &Result{Commits: []*commit.Commit{}}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if data.Metadata == nil {
+				data.Metadata = &RepoMetadata{}
+				if jsonErr := json.Unmarshal(line, data.Metadata); jsonErr != nil {
+					return nil, jsonErr
+				}
+			} else {
+				c := &commit.Commit{}
+				if jsonErr := json.Unmarshal(line, c); jsonErr != nil {
+					return nil, jsonErr
+				}
+				data.Commits = append(data.Commits, c)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if data.Metadata == nil {
+		return nil, fmt.Errorf("%s has no metadata", path)
+	}
+	return data, nil
+}
+
+// appendPrevious adds the commits of the earlier incremental extractions to the user's commits
+// The totals which aggregate doesn't compute from the commits are added up too. DominatedFiles
+// and Leaderboard only cover the commits read by this extraction.
+func (r *RepoExtractor) appendPrevious() {
+	seen := map[string]bool{}
+	for _, c := range r.userCommits {
+		seen[c.Hash] = true
+	}
+	commits := make([]*commit.Commit, 0, len(r.previous.Commits)+len(r.userCommits))
+	for _, c := range r.previous.Commits {
+		if !seen[c.Hash] {
+			commits = append(commits, c)
+			r.repo.WeightedScore += c.WeightedScore
+		}
+	}
+	r.userCommits = append(commits, r.userCommits...)
+
+	r.repo.OrphanedCommits += r.previous.Metadata.OrphanedCommits
+	introduced := map[string]bool{}
+	for _, language := range r.repo.LanguagesIntroduced {
+		introduced[language] = true
+	}
+	for _, language := range r.previous.Metadata.LanguagesIntroduced {
+		if !introduced[language] {
+			introduced[language] = true
+			r.repo.LanguagesIntroduced = append(r.repo.LanguagesIntroduced, language)
+		}
+	}
+}
+
+// saveCursor writes the results and the newest commit of the extraction into the cursor files
+// The results are written first, so the cursor never moves past the saved commits.
+func (r *RepoExtractor) saveCursor() error {
+	err := replaceFile(r.cursorDataPath(), r.writeRepoDataTo)
+	if err != nil {
+		return fmt.Errorf("cannot save the results of the cursor %s: %w", r.cursorDataPath(), err)
+	}
+	err = replaceFile(r.cursorPath(), func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, r.newestCommit)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot save the cursor %s: %w", r.cursorPath(), err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Incremental", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"a.go": "package a\n"}})
+		repo.commit(testCommit{Files: map[string]string{"b.go": "package b\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	cursorPath := func() string {
+		return filepath.Join(outputDir, "repo_data.repo.cursor")
+	}
+
+	// incremental keeps the output next to the cursor, so the next run appends to it
+	incremental := func(re *extractor.RepoExtractor) {
+		re.OutputPath = filepath.Join(outputDir, "repo_data")
+		re.SkipLibraries = true
+		re.Incremental = true
+	}
+
+	// extract returns the hashes of the extracted commits
+	extract := func() []string {
+		_, commits := extractRepo(repo, incremental)
+		hashes := []string{}
+		for _, c := range commits {
+			hashes = append(hashes, c.Hash)
+		}
+		return hashes
+	}
+
+	readCursor := func() string {
+		content, err := ioutil.ReadFile(cursorPath())
+		Expect(err).ShouldNot(HaveOccurred())
+		return strings.TrimSpace(string(content))
+	}
+
+	It("should append the new commits of the next run", func() {
+		first := extract()
+		Expect(first).To(HaveLen(2))
+		Expect(readCursor()).To(Equal(repo.git("rev-parse", "HEAD")))
+
+		third := repo.commit(testCommit{Files: map[string]string{"c.go": "package c\n"}})
+		fourth := repo.commit(testCommit{Files: map[string]string{"d.go": "package d\n"}})
+		metadata, commits := extractRepo(repo, incremental)
+		hashes := []string{}
+		for _, c := range commits {
+			hashes = append(hashes, c.Hash)
+		}
+		Expect(hashes).To(ConsistOf(append(first, third, fourth)))
+		Expect(metadata["languageAddDelete"]).To(HaveKeyWithValue("Go", HaveKeyWithValue("insertions", BeEquivalentTo(4))))
+		Expect(readCursor()).To(Equal(fourth))
+
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, UserEmails: []string{"test@example.com"}}
+		incremental(&re)
+		Expect(re.Extract()).To(MatchError(extractor.ErrNoCommits))
+		Expect(readCursor()).To(Equal(fourth))
+	})
+
+	It("should read every commit if the cursor doesn't exist anymore", func() {
+		Expect(ioutil.WriteFile(cursorPath(), []byte("0123456789012345678901234567890123456789\n"), 0644)).Should(Succeed())
+		Expect(extract()).To(HaveLen(2))
+		Expect(readCursor()).To(Equal(repo.git("rev-parse", "HEAD")))
+	})
+
+	It("should read every commit if the results of the cursor are missing", func() {
+		Expect(extract()).To(HaveLen(2))
+		Expect(os.Remove(cursorPath() + ".data")).Should(Succeed())
+		third := repo.commit(testCommit{Files: map[string]string{"c.go": "package c\n"}})
+		Expect(extract()).To(HaveLen(3))
+		Expect(readCursor()).To(Equal(third))
+	})
+
+	It("should refuse ranges", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, Incremental: true, Ref: "HEAD~1..HEAD"}
+		Expect(re.Extract()).To(MatchError("step loadCursor: incremental extraction needs a single ref, got the range HEAD~1..HEAD"))
+	})
+})
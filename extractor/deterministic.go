@@ -0,0 +1,43 @@
+package extractor
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// deterministicModTime is the modification time of the archived files if Deterministic is set
+var deterministicModTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// sortOutput orders the commits by date and hash, their changed files by path and their libraries by name
+// The workers finish in any order, so the output is only stable after sorting.
+func (r *RepoExtractor) sortOutput() {
+	sort.SliceStable(r.userCommits, func(i, j int) bool {
+		a, _ := commitTime(r.userCommits[i])
+		b, _ := commitTime(r.userCommits[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return r.userCommits[i].Hash < r.userCommits[j].Hash
+	})
+	for _, c := range r.userCommits {
+		sort.SliceStable(c.ChangedFiles, func(i, j int) bool {
+			return c.ChangedFiles[i].Path < c.ChangedFiles[j].Path
+		})
+		for _, libraries := range c.Libraries {
+			sort.Strings(libraries)
+		}
+	}
+	sort.Strings(r.repo.SuggestedEmails)
+}
+
+// setDeterministicModTimes sets the same modification time on the files, it ends up in the archive
+func setDeterministicModTimes(paths []string) error {
+	for _, path := range paths {
+		err := os.Chtimes(path, deterministicModTime, deterministicModTime)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// stdout is where ListEmailsOnly prints the emails, it is replaced in the tests
+var stdout io.Writer = os.Stdout
+
+// EmailCount is an author email of the repo with the number of its commits
+type EmailCount struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Commits int    `json:"commits"`
+}
+
+// listEmails prints the emails of the repo with their commit counts as JSON
+func (r *RepoExtractor) listEmails(ctx context.Context, w io.Writer) error {
+	commits, err := r.getCommits(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = r.mapAuthors(commits)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(emailCounts(commits))
+}
+
+// emailCounts counts the commits of each email, the most active first
+// The name is taken from the first commit of the email.
+func emailCounts(commits []*commit.Commit) []EmailCount {
+	counts := []EmailCount{}
+	indexes := make(map[string]int)
+	for _, c := range commits {
+		i, ok := indexes[c.AuthorEmail]
+		if !ok {
+			i = len(counts)
+			indexes[c.AuthorEmail] = i
+			counts = append(counts, EmailCount{Name: c.AuthorName, Email: c.AuthorEmail})
+		}
+		counts[i].Commits++
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Commits != counts[j].Commits {
+			return counts[i].Commits > counts[j].Commits
+		}
+		return counts[i].Email < counts[j].Email
+	})
+	return counts
+}
+
+// emailsFromEnv returns the comma separated emails of the CODERSRANK_EMAILS environment variable
+func emailsFromEnv() []string {
+	emails := []string{}
+	for _, email := range strings.Split(os.Getenv("CODERSRANK_EMAILS"), ",") {
+		email = strings.TrimSpace(email)
+		if email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// emailDomain returns the lowercase part of the email after the last @, "" if there is none
+func emailDomain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i == -1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[i+1:]))
+}
@@ -0,0 +1,98 @@
+package extractor_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("ListEmailsOnly", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Name: "Alice", Email: "alice@example.com", Files: map[string]string{"a.go": "a\n"}})
+		repo.commit(testCommit{Name: "Bob", Email: "bob@example.com", Files: map[string]string{"b.go": "b\n"}})
+		repo.commit(testCommit{Name: "Alice", Email: "alice@example.com", Files: map[string]string{"a.go": "aa\n"}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should print the emails with their commit counts without exporting", func() {
+		outputDir, err := ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(outputDir)
+
+		output := &bytes.Buffer{}
+		defer extractor.SetStdout(output)()
+		// Headless is off, so a prompt would fail without a terminal
+		defer extractor.SetIsTerminal(func() bool { return false })()
+
+		re := extractor.RepoExtractor{
+			RepoPath:       repo.Path,
+			OutputPath:     filepath.Join(outputDir, "repo_data"),
+			ListEmailsOnly: true,
+		}
+		Expect(re.Extract()).Should(Succeed())
+
+		var emails []extractor.EmailCount
+		Expect(json.Unmarshal(output.Bytes(), &emails)).Should(Succeed())
+		Expect(emails).To(Equal([]extractor.EmailCount{
+			{Name: "Alice", Email: "alice@example.com", Commits: 2},
+			{Name: "Bob", Email: "bob@example.com", Commits: 1},
+		}))
+
+		files, err := ioutil.ReadDir(outputDir)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+})
+
+var _ = Describe("CODERSRANK_EMAILS", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Name: "Alice", Email: "alice@example.com", Files: map[string]string{"a.go": "a\n"}})
+		repo.commit(testCommit{Name: "Bob", Email: "bob@example.com", Files: map[string]string{"b.go": "b\n"}})
+		repo.commit(testCommit{Name: "Carol", Email: "carol@example.com", Files: map[string]string{"c.go": "c\n"}})
+		os.Setenv("CODERSRANK_EMAILS", " alice@example.com, carol@example.com,")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("CODERSRANK_EMAILS")
+		repo.remove()
+	})
+
+	extract := func(userEmails []string) (map[string]interface{}, []string) {
+		metadata, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.UserEmails = userEmails
+		})
+		authors := []string{}
+		for _, c := range commits {
+			authors = append(authors, c.AuthorEmail)
+		}
+		return metadata, authors
+	}
+
+	It("should use the emails of the environment in headless mode", func() {
+		metadata, authors := extract(nil)
+		Expect(metadata["emails"]).To(ConsistOf("alice@example.com", "carol@example.com"))
+		Expect(authors).To(ConsistOf("alice@example.com", "carol@example.com"))
+	})
+
+	It("should prefer UserEmails", func() {
+		_, authors := extract([]string{"bob@example.com"})
+		Expect(authors).To(ConsistOf("bob@example.com"))
+	})
+})
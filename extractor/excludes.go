@@ -0,0 +1,78 @@
+package extractor
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// DefaultExcludePatterns match the usual vendored, generated and minified files
+var DefaultExcludePatterns = []string{
+	"vendor",
+	"node_modules",
+	"bower_components",
+	"dist",
+	"*.min.js",
+	"*.min.css",
+	"*.js.map",
+}
+
+// excludePatterns returns ExcludePatterns after checking that they are valid
+func (r *RepoExtractor) excludePatterns() ([]string, error) {
+	patterns := r.ExcludePatterns
+	if patterns == nil {
+		patterns = DefaultExcludePatterns
+	}
+	for _, pattern := range patterns {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %s: %w", pattern, err)
+		}
+	}
+	return patterns, nil
+}
+
+// excludeFiles removes the changed files matching ExcludePatterns from the commits
+func (r *RepoExtractor) excludeFiles(commits []*commit.Commit) error {
+	patterns, err := r.excludePatterns()
+	if err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	for _, c := range commits {
+		changedFiles := c.ChangedFiles[:0]
+		for _, f := range c.ChangedFiles {
+			if !isExcluded(f.Path, patterns) {
+				changedFiles = append(changedFiles, f)
+			}
+		}
+		c.ChangedFiles = changedFiles
+		c.CountChangedFiles()
+	}
+	return nil
+}
+
+// isExcluded reports whether a pattern matches the path or one of its parent directories
+// Patterns without a slash match any single element of the path, e.g. "vendor" matches "a/vendor/b.go".
+func isExcluded(filePath string, patterns []string) bool {
+	elements := strings.Split(filePath, "/")
+	for _, pattern := range patterns {
+		for i := range elements {
+			// Ignoring the error, the patterns are checked by excludePatterns
+			if matched, _ := path.Match(pattern, strings.Join(elements[:i+1], "/")); matched {
+				return true
+			}
+			if strings.Contains(pattern, "/") {
+				continue
+			}
+			if matched, _ := path.Match(pattern, elements[i]); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("ExcludePatterns", func() {
+	var repo *testRepo
+	var first, second string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		first = repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		second = repo.commit(testCommit{Files: map[string]string{
+			"main.go":                         "package main\n",
+			"vendor/github.com/pkg/errors.go": "package errors\n",
+			"web/node_modules/react/index.js": "module.exports = {}\n",
+			"web/static/app.min.js":           "var a=1;\n",
+			"web/src/app.js":                  "var a = 1;\n",
+			"generated/api.pb.go":             "package api\n",
+			"distribution/config.go":          "package distribution\n",
+		}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	paths := func(re extractor.RepoExtractor) []string {
+		re.RepoPath = repo.Path
+		result, err := re.ExtractRange(first, second)
+		Expect(err).ShouldNot(HaveOccurred())
+		paths := []string{}
+		for _, f := range result.Commits[0].ChangedFiles {
+			paths = append(paths, f.Path)
+		}
+		return paths
+	}
+
+	It("should leave out the vendored and minified files by default", func() {
+		Expect(paths(extractor.RepoExtractor{})).To(ConsistOf(
+			"main.go",
+			"web/src/app.js",
+			"generated/api.pb.go",
+			"distribution/config.go",
+		))
+	})
+
+	It("should use the custom patterns instead of the defaults", func() {
+		Expect(paths(extractor.RepoExtractor{ExcludePatterns: []string{"generated/*.pb.go", "web"}})).To(ConsistOf(
+			"main.go",
+			"vendor/github.com/pkg/errors.go",
+			"distribution/config.go",
+		))
+	})
+
+	It("should keep every file with an empty list", func() {
+		Expect(paths(extractor.RepoExtractor{ExcludePatterns: []string{}})).To(HaveLen(7))
+	})
+
+	It("should fail for invalid patterns", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, ExcludePatterns: []string{"["}}
+		_, err := re.ExtractRange(first, second)
+		Expect(err).Should(HaveOccurred())
+	})
+})
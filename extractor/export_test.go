@@ -0,0 +1,110 @@
+package extractor
+
+import (
+	"context"
+	"io"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// Unexported functions used by the tests in extractor_test
+var (
+	GetAllEmails          = getAllEmails
+	GetEmailsWithoutNames = getEmailsWithoutNames
+	TopDirectories        = topDirectories
+	CommitGaps            = commitGaps
+	CommitRange           = commitRange
+	DominatedFiles        = dominatedFiles
+	LanguagesIntroduced   = languagesIntroduced
+	Leaderboard           = leaderboard
+	WeeklyActivity        = weeklyActivity
+	LanguageAddDelete     = languageAddDelete
+	LanguageSummary       = languageSummary
+	DetectProvider        = detectProvider
+)
+
+// ParseGitLog parses the git log like parseGitLog with the default logger
+func ParseGitLog(reader io.Reader) ([]*commit.Commit, error) {
+	return parseGitLog(reader, defaultLogger)
+}
+
+// SetArchive replaces the creation of the zip, the returned function restores it
+func SetArchive(f func(sources []string, destination string) error) func() {
+	original := archive
+	archive = f
+	return func() {
+		archive = original
+	}
+}
+
+// SetIsTerminal replaces the terminal detection, the returned function restores it
+func SetIsTerminal(f func() bool) func() {
+	original := isTerminal
+	isTerminal = f
+	return func() {
+		isTerminal = original
+	}
+}
+
+// SetAskUpload replaces the upload confirmation prompt, the returned function restores it
+func SetAskUpload(f func(url string) bool) func() {
+	original := askUpload
+	askUpload = f
+	return func() {
+		askUpload = original
+	}
+}
+
+// SetStdout replaces the writer of ListEmailsOnly, the returned function restores it
+func SetStdout(w io.Writer) func() {
+	original := stdout
+	stdout = w
+	return func() {
+		stdout = original
+	}
+}
+
+// SetCommitsPerJob replaces the number of commits read by a commit worker job, the returned function restores it
+func SetCommitsPerJob(n int) func() {
+	original := commitsPerJob
+	commitsPerJob = n
+	return func() {
+		commitsPerJob = original
+	}
+}
+
+// SetSelectRemote replaces the remote prompt, the returned function restores it
+func SetSelectRemote(f func(remotes []string) string) func() {
+	original := selectRemote
+	selectRemote = f
+	return func() {
+		selectRemote = original
+	}
+}
+
+// PrimaryRemoteURL returns the URL of the remote defining the name of the repo
+func (r *RepoExtractor) PrimaryRemoteURL() string {
+	r.initGit()
+	remotes, _ := r.getRemotes()
+	primary, _ := r.primaryRemote(remotes)
+	return primary.URL
+}
+
+// NewBlobReader starts a blob reader for the repo in repoPath
+func NewBlobReader(gitPath, repoPath string) (*blobReader, error) {
+	r := &RepoExtractor{GitPath: gitPath, RepoPath: repoPath}
+	return newBlobReader(r.gitCommandContext(context.Background(), "cat-file", "--batch"))
+}
+
+// Read exposes the reading of the file contents
+func (b *blobReader) Read(hash, path string) ([]byte, bool, error) {
+	return b.read(hash, path)
+}
+
+// GitRunner lets the tests implement fake git runners
+type GitRunner = gitRunner
+
+// SetGitRunner replaces the git runner of the extractor
+func (r *RepoExtractor) SetGitRunner(runner GitRunner) {
+	r.gitRunner = runner
+}
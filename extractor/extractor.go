@@ -2,17 +2,16 @@ package extractor
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"golang.org/x/text/language"
-	"golang.org/x/text/search"
-	"log"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
@@ -30,42 +29,195 @@ import (
 // Including cloning the repo, processing the commits and uploading the results
 type RepoExtractor struct {
 	RepoPath            string
-	OutputPath          string
+	RemoteURL           string // If set and RepoPath and GitDir are empty the repo is cloned into a temporary directory
+	GitDir              string // If set it is passed to git as --git-dir instead of running git in RepoPath
+	WorkTree            string // If set it is passed to git as --work-tree instead of running git in RepoPath
+	RemoteName          string // Remote defining the name of the repo. Defaults to origin, otherwise the user chooses.
+	Ref                 string // Branch, tag, commit or range like v1.0..v2.0 to analyse. Defaults to every ref.
+	SinceDate           string // If set only the commits after it are read, it is passed to git log as --since, e.g. 2020-01-01
+	MaxCommits          int    // If it is positive only the most recent commits of the repo are read, of every author. It can't be combined with Incremental.
+	ConfigPath          string // JSON file with the settings of the fields left unset, see Config
+	Incremental         bool   // If it is true only the commits after the cursor of the last run are read, up to Ref or HEAD, and appended to the commits of the earlier runs. Without new commits it returns ErrNoCommits.
+	CursorPath          string // File of the cursor of Incremental. Defaults to <OutputPath>.repo.cursor, the results so far are kept in <CursorPath>.data
+	CloneDepth          int    // Depth of the shallow clone of RemoteURL. Defaults to DefaultCloneDepth.
+	FullClone           bool   // If it is true RemoteURL is cloned with the full history
+	OutputPath          string // Prefix of the output files, e.g. ./repo_data_v2 results in ./repo_data_v2_v2.json.zip. Defaults to DefaultOutputPath.
 	GitPath             string
+	Version             string // Version of the extractor, it is written into the output
 	Headless            bool
+	Upload              bool // If it is true the results are uploaded to CodersRank, even in headless mode
+	NoUpload            bool // If it is true nothing is uploaded, even in interactive mode. It wins over Upload.
+	AssumeYes           bool // If it is true the interactive runs upload without asking the user
 	Obfuscate           bool
-	ShowProgressBar     bool // If it is false there is no progress bar.
-	SkipLibraries       bool // If it is false there is no library detection.
-	UserEmails          []string
-	OverwrittenRepoName string // If set this will be used instead of the
+	ShowProgressBar     bool     // If it is false there is no progress bar.
+	Concurrency         int      // Number of workers reading the commits and detecting the libraries. Defaults to the number of CPUs.
+	SkipLibraries       bool     // If it is false there is no library detection.
+	MaxFileBytes        int      // Larger files are left out of the library detection and get their language from the name. The files of the file analyzers, like lockfiles, are always read. Defaults to DefaultMaxFileBytes, negative keeps every file.
+	RecordBlobHashes    bool     // If it is true the blob hash of every changed file is recorded
+	SkipDocOnlyCommits  bool     // If it is true commits changing only documentation are left out.
+	SkipMailmap         bool     // If it is true the author names and emails are not mapped by .mailmap
+	ReadReflog          bool     // If it is true the commits of the reflogs are read too, even if no ref reaches them. It can't be combined with Ref.
+	SkipOrphanedCommits bool     // If it is true the commits which no ref reaches are flagged and left out
+	AuthorMappingPath   string   // CSV file mapping raw emails to canonical identities, applied after .mailmap
+	ComputeOwnership    bool     // If it is true the files dominated by the user are calculated from every commit.
+	ComputeLeaderboard  bool     // If it is true the stats of every author are calculated
+	UserCommitsOnly     bool     // If it is true and the emails are known git log reads the commits of the user only. LanguagesIntroduced is left empty, Seed, ComputeOwnership, ComputeLeaderboard, DetectSquashes and MaxCommits read every commit.
+	MinChurn            int      // Commits of the user changing fewer lines are left out
+	DetectSquashes      bool     // If it is true the commits looking like squash merges are flagged
+	SquashSizeFactor    float64  // Commits changing this many times more files and lines than the median are likely squashes. Defaults to DefaultSquashSizeFactor.
+	ListEmailsOnly      bool     // If it is true the emails are printed to stdout as JSON with their commit counts, nothing is exported.
+	UserEmails          []string // In headless mode defaults to the comma separated CODERSRANK_EMAILS environment variable
+	OverwrittenRepoName string   // If set this will be used instead of the
+	OutputLayout        string   // LayoutSingle (default) or LayoutPerLanguage
+	OutputFormat        string   // FormatZip (default), FormatGzip or FormatJSONL
+	KeepRawData         bool     // If it is true the uncompressed output is kept next to the zip, the per language files in <OutputPath>_v2_languages
+	Deterministic       bool     // If it is true the commits, files and libraries are sorted, so extracting the same repo results in the same bytes
 	Seed                []string
-	repo                *repo
+	UploadURL           string              // Defaults to DefaultUploadURL
+	UploadToken         string              // Defaults to the CODERSRANK_TOKEN environment variable
+	UploadAttempts      int                 // How many times the upload is tried. Defaults to 3.
+	UploadRetryDelay    time.Duration       // Delay before the first retry, it doubles after every attempt. Defaults to 1s.
+	CommandTimeout      time.Duration       // The git commands of the workers taking longer are killed. A git log fails the extraction, the files are skipped. Defaults to DefaultCommandTimeout, negative turns it off.
+	ExcludePatterns     []string            // Glob patterns of the paths left out of the analysis. Defaults to DefaultExcludePatterns, an empty slice turns off the exclusion.
+	ReferencePatterns   []string            // Regexes of issue and pull request references in commit messages. Defaults to DefaultReferencePatterns.
+	InactivityThreshold time.Duration       // Longer gaps between commits are left out of the commit gap stats. Zero keeps every gap.
+	GroupDepth          int                 // Depth of the directories in TopDirectories, e.g. src/a/b.go belongs to src at 1 and to src/a at 2. Defaults to DefaultGroupDepth.
+	PathWeights         map[string]float64  // Weights of the changed lines in the weighted scores by glob pattern, matched like ExcludePatterns. The highest matching weight wins, other files weigh 1.
+	TimeZoneSource      string              // Date of the commits counted in the time zone stats, DateSourceAuthor (default) or DateSourceCommitter
+	ProgressFunc        func(processed int) // Called with the number of commits read so far. By default it prints to stderr if there is no progress bar.
+	Logger              Logger              // Receives the status messages, the workers log concurrently. Defaults to a logger writing to stderr.
+	Verbose             bool                // If it is true every commit and file is logged too
+	LogSource           io.Reader           // If set commits are parsed from it instead of running git log. It must be formatted with logFormat, logs of the older |||SEP||| format are read too.
+	repo                *RepoMetadata
+	attributeRules      []attributeRule  // Linguist rules of .gitattributes
 	userCommits         []*commit.Commit // Commits which are belong to user (from selected emails)
+	defaultBranch       string           // Name of the default branch, empty if it is unknown
+	defaultRef          string           // Full ref of the default branch, like refs/remotes/origin/main
+	cursor              string           // Newest commit of the last incremental extraction
+	newestCommit        string           // Newest commit of this incremental extraction, saved as the next cursor
+	previous            *Result          // Results of the earlier incremental extractions, nil without a cursor
+	gitRunner           gitRunner        // Runs the git commands, nil runs GitPath
+	authorFilter        []string         // Emails of the authors whose commits git log reads, empty reads every commit
+	mainRepoDir         string           // Main repo of the linked worktree in RepoPath, empty otherwise
+	extracted           bool             // The output is complete, set before it is emitted
 }
 
 // Extract a single repo in the path
 func (r *RepoExtractor) Extract() error {
+	return r.ExtractContext(context.Background())
+}
 
-	r.initGit()
+// ExtractContext extracts a single repo in the path like Extract
+// Cancelling ctx kills the running git processes and ExtractContext returns an error wrapping ctx.Err().
+func (r *RepoExtractor) ExtractContext(ctx context.Context) error {
+	return r.extract(ctx, func() error {
+		err := r.export()
+		if err != nil {
+			return fmt.Errorf("step export: %w", err)
+		}
 
-	err := r.initRepo()
+		if r.shouldUpload() {
+			if !r.confirmUpload() {
+				r.logf("The results are not uploaded, they are in %s", r.outputFilePath())
+				return nil
+			}
+			err = r.upload()
+			if err != nil {
+				return fmt.Errorf("step upload: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// extract analyses the repo and calls emit to output the results
+func (r *RepoExtractor) extract(ctx context.Context, emit func() error) error {
+	r.extracted = false
+	err := r.applyConfig()
+	if err != nil {
+		return fmt.Errorf("step applyConfig: %w", err)
+	}
+	if r.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative, got %d", r.Concurrency)
+	}
+	if r.Ref != "" && r.ReadReflog {
+		return errors.New("Ref can't be combined with ReadReflog")
+	}
+	if r.GroupDepth < 0 {
+		return fmt.Errorf("group depth must not be negative, got %d", r.GroupDepth)
+	}
+	// The cursor would move past the commits left out
+	if r.Incremental && r.MaxCommits > 0 {
+		return errors.New("MaxCommits can't be combined with Incremental")
+	}
+	err = r.validateTimeZoneSource()
+	if err != nil {
+		return err
+	}
+	err = r.validatePathWeights()
 	if err != nil {
-		fmt.Println("Cannot init repo_info_extractor. Error: ", err.Error())
 		return err
 	}
 
+	r.initGit()
+
+	if r.RemoteURL != "" && r.RepoPath == "" && r.GitDir == "" {
+		cleanup, err := r.cloneRemote()
+		if err != nil {
+			return fmt.Errorf("step cloneRemote: %w", err)
+		}
+		defer cleanup()
+	}
+
+	if r.LogSource == nil {
+		err = r.verifyRepo()
+		if err != nil {
+			return fmt.Errorf("step verifyRepo: %w", err)
+		}
+		r.initMainRepoDir()
+		r.initDefaultBranch()
+		err = r.verifyRef()
+		if err != nil {
+			return fmt.Errorf("step verifyRef: %w", err)
+		}
+		if r.Incremental {
+			err = r.loadCursor()
+			if err != nil {
+				return fmt.Errorf("step loadCursor: %w", err)
+			}
+		}
+	}
+
+	if r.ListEmailsOnly {
+		return r.listEmails(ctx, stdout)
+	}
+
+	err = r.initRepo()
+	if err != nil {
+		r.logf("Cannot init repo_info_extractor. Error: %s", err.Error())
+		return fmt.Errorf("step initRepo: %w", err)
+	}
+
 	// For library detection
 	r.initAnalyzers()
+	r.initAttributes()
 
-	err = r.analyseCommits()
+	err = r.analyseCommits(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("step analyseCommits: %w", err)
 	}
 
 	if !r.SkipLibraries {
-		err = r.analyseLibraries()
+		err = r.analyseLibraries(ctx)
 		if err != nil {
-			return err
+			return fmt.Errorf("step analyseLibraries: %w", err)
+		}
+	}
+
+	if r.RecordBlobHashes {
+		err = r.setBlobHashes(r.userCommits)
+		if err != nil {
+			return fmt.Errorf("step setBlobHashes: %w", err)
 		}
 	}
 
@@ -73,22 +225,144 @@ func (r *RepoExtractor) Extract() error {
 		r.obfuscate()
 	}
 
-	err = r.export()
+	// The earlier commits are already obfuscated
+	if r.previous != nil {
+		r.appendPrevious()
+	}
+
+	r.aggregate()
+
+	if r.OverwrittenRepoName != "" {
+		r.repo.RepoName = r.OverwrittenRepoName
+	}
+
+	if r.Deterministic {
+		r.sortOutput()
+	}
+
+	// Nothing is written if the extraction was cancelled in the meantime
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	r.extracted = true
+	err = emit()
 	if err != nil {
 		return err
 	}
 
-	// Only when user running this script locally
-	if !r.Headless {
-		err = r.upload()
+	// The cursor only moves once the commits are written
+	if r.Incremental && r.LogSource == nil {
+		err = r.saveCursor()
 		if err != nil {
-			return err
+			return fmt.Errorf("step saveCursor: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExtractRange returns the commits reachable from b but not from a (git log a..b)
+// It doesn't walk the whole history, select emails nor write any output file
+func (r *RepoExtractor) ExtractRange(a, b string) (*Result, error) {
+	r.initGit()
+
+	for _, rev := range []string{a, b} {
+		err := r.verifyCommit(rev)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	commits, err := r.readLog(context.Background(),
+		"-c", "core.quotePath=false",
+		"log",
+		"--numstat",
+		"--summary",
+		r.prettyFormat(),
+		"--no-merges",
+		fmt.Sprintf("%s..%s", a, b),
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = r.excludeFiles(commits)
+	if err != nil {
+		return nil, err
+	}
+	err = r.setReferences(commits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Commits: commits,
+	}, nil
+}
+
+// verifyCommit returns an error if rev doesn't point to a commit in the repo
+func (r *RepoExtractor) verifyCommit(rev string) error {
+	_, err := r.runGit(
+		"rev-parse",
+		"--verify",
+		"--quiet",
+		rev+"^{commit}",
+	)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid commit", rev)
+	}
+	return nil
+}
+
+// verifyRepo returns an error if there is no git repo in the directory of the repo
+// Bare repos pass too, they are not inside a work tree but git finds them.
+func (r *RepoExtractor) verifyRepo() error {
+	_, err := r.runGit("rev-parse", "--is-inside-work-tree")
+	if err != nil {
+		dir := r.repoDir()
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		// Git didn't run at all, e.g. because of a wrong GitPath
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("cannot run git in %s: %w", dir, err)
+		}
+		return fmt.Errorf("%s is not a git repository: %w", dir, err)
+	}
 	return nil
 }
 
+// gitCommandContext returns a git command running in the repo which is killed when ctx is done
+// It is only used for git cat-file --batch, which reads the requests from stdin. Use runGit otherwise.
+func (r *RepoExtractor) gitCommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	dir, gitArgs := r.gitArgs(args)
+	cmd := exec.CommandContext(ctx, r.GitPath, gitArgs...)
+	cmd.Dir = dir
+	cmd.Env = gitEnv()
+	return cmd
+}
+
+// workers returns the number of concurrent workers reading the commits and detecting the libraries
+func (r *RepoExtractor) workers() int {
+	if r.Concurrency > 0 {
+		return r.Concurrency
+	}
+	return runtime.NumCPU()
+}
+
+// repoDir returns the directory of the repo, the work tree or the git directory if RepoPath is empty
+// For a linked worktree it is the main repo, the worktree is usually named after a branch.
+func (r *RepoExtractor) repoDir() string {
+	switch {
+	case r.mainRepoDir != "":
+		return r.mainRepoDir
+	case r.RepoPath != "":
+		return r.RepoPath
+	case r.WorkTree != "":
+		return r.WorkTree
+	}
+	return r.GitDir
+}
+
 func (r *RepoExtractor) initGit() {
 	// Git path already provided by user
 	if r.GitPath != "" {
@@ -98,7 +372,7 @@ func (r *RepoExtractor) initGit() {
 	gitPath, err := exec.LookPath("git")
 	if err != nil {
 		defaultGitPath := "/usr/bin/git"
-		fmt.Printf("Couldn't find git path. Fall back to default (%s). Error: %s.\n", defaultGitPath, err.Error())
+		r.logf("Couldn't find git path. Fall back to default (%s). Error: %s.", defaultGitPath, err.Error())
 		// Try default git path
 		r.GitPath = defaultGitPath
 		return
@@ -111,31 +385,31 @@ func (r *RepoExtractor) initGit() {
 
 // Creates Repo struct
 func (r *RepoExtractor) initRepo() error {
-	fmt.Println("Initializing repository")
+	r.logf("Initializing repository")
 
-	cmd := exec.Command(r.GitPath,
-		"config",
-		"--get",
-		"remote.origin.url",
-	)
-	cmd.Dir = r.RepoPath
-
-	out, err := cmd.CombinedOutput()
+	remoteOrigin := ""
+	remotes, err := r.getRemotes()
 	if err != nil {
-		fmt.Println("Cannot get remote.origin.url. Use directory path to get repo name.")
+		r.logf("Cannot list the remotes. Error: %s", err.Error())
+	}
+	primary, ok := r.primaryRemote(remotes)
+	if ok {
+		remoteOrigin = primary.URL
+	} else {
+		r.logf("Cannot get the URL of the remote. Use directory path to get repo name.")
 	}
 
-	repoName := ""
-	remoteOrigin := string(out)
-	remoteOrigin = strings.TrimRight(remoteOrigin, "\r\n")
-	remoteOrigin = strings.TrimRight(remoteOrigin, "\n")
-
-	repoName = r.GetRepoName(remoteOrigin)
-
-	r.repo = &repo{
-		RepoName:        repoName,
-		Emails:          []string{},
-		SuggestedEmails: []string{}, // TODO implement
+	repoName := r.GetRepoName(remoteOrigin)
+
+	r.repo = &RepoMetadata{
+		SchemaVersion:    SchemaVersion,
+		ExtractorVersion: r.Version,
+		RepoName:         repoName,
+		PrimaryRemoteURL: remoteOrigin,
+		Provider:         detectProvider(remoteOrigin),
+		DefaultBranch:    r.defaultBranch,
+		Emails:           []string{},
+		SuggestedEmails:  []string{}, // TODO implement
 	}
 	return nil
 }
@@ -146,8 +420,11 @@ func (r *RepoExtractor) initRepo() error {
 func (r *RepoExtractor) GetRepoName(remoteOrigin string) string {
 	// If remoteOrigin is empty fall back to the repos path. It can happen in interactive mode
 	if remoteOrigin == "" {
-		parts := strings.Split(r.RepoPath, "/")
-		return parts[len(parts)-1]
+		path := r.repoDir()
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		return filepath.Base(path)
 	}
 	repoName := ""
 	remoteOrigin = strings.TrimSuffix(remoteOrigin, ".git")
@@ -187,22 +464,72 @@ func (r *RepoExtractor) initAnalyzers() {
 	librarydetection.AddAnalyzer("Perl", languages.NewPerlAnalyzer())
 	librarydetection.AddAnalyzer("PHP", languages.NewPHPAnalyzer())
 	librarydetection.AddAnalyzer("Python", languages.NewPythonScriptAnalyzer())
+	librarydetection.AddAnalyzer("Ruby", languages.NewRubyAnalyzer())
+	librarydetection.AddAnalyzer("Rust", languages.NewRustAnalyzer())
 	librarydetection.AddAnalyzer("Swift", languages.NewSwiftAnalyzer())
+	for _, language := range []string{"CSS", "Less", "SASS", "SCSS"} {
+		librarydetection.AddAnalyzer(language, languages.NewStylesheetAnalyzer())
+	}
+
+	// Manifests record the declared dependencies of the project
+	librarydetection.AddFileAnalyzer("Package.swift", "Swift", languages.NewSwiftPackageAnalyzer())
+	librarydetection.AddFileAnalyzer("Gemfile", "Ruby", languages.NewGemfileAnalyzer())
+	librarydetection.AddFileAnalyzer("*.gemspec", "Ruby", languages.NewGemfileAnalyzer())
+	librarydetection.AddFileAnalyzer("composer.json", "PHP", languages.NewComposerAnalyzer())
+	librarydetection.AddFileAnalyzer("go.mod", "Go", languages.NewGoModAnalyzer())
+	librarydetection.AddFileAnalyzer("requirements.txt", "Python", languages.NewRequirementsAnalyzer())
+	librarydetection.AddFileAnalyzer("Pipfile", "Python", languages.NewPipfileAnalyzer())
+	librarydetection.AddFileAnalyzer("Cargo.toml", "Rust", languages.NewCargoAnalyzer())
+	// Maven and Gradle builds don't tell Java and Kotlin projects apart
+	librarydetection.AddFileAnalyzer("pom.xml", "Java", languages.NewPomAnalyzer())
+	librarydetection.AddFileAnalyzer("build.gradle", "Java", languages.NewGradleAnalyzer())
+	librarydetection.AddFileAnalyzer("build.gradle.kts", "Java", languages.NewGradleAnalyzer())
+	// Manifests and lockfiles depending on typescript are recorded for TypeScript, see dependsOnTypeScript
+	librarydetection.AddFileAnalyzer("package.json", "JavaScript", languages.NewPackageJSONAnalyzer())
+	librarydetection.AddFileAnalyzer("yarn.lock", "JavaScript", languages.NewYarnLockAnalyzer())
+	librarydetection.AddFileAnalyzer("package-lock.json", "JavaScript", languages.NewPackageLockAnalyzer())
 }
 
 // Creates commits
-func (r *RepoExtractor) analyseCommits() error {
-	fmt.Println("Analysing commits")
+func (r *RepoExtractor) analyseCommits(ctx context.Context) error {
+	r.logf("Analysing commits")
+
+	// CI jobs can pass the emails in the environment
+	if len(r.UserEmails) == 0 && r.Headless {
+		r.UserEmails = emailsFromEnv()
+	}
+	err := r.initAuthorFilter()
+	if err != nil {
+		return err
+	}
 
 	var commits []*commit.Commit
 	userCommits := make([]*commit.Commit, 0, len(commits))
-	commits, err := r.getCommits()
-	if len(commits) == 0 {
-		return nil
+	commits, err = r.getCommits(ctx)
+	if err != nil {
+		return err
+	}
+	authorMapping, err := r.mapAuthors(commits)
+	if err != nil {
+		return err
 	}
+	err = r.excludeFiles(commits)
 	if err != nil {
 		return err
 	}
+	if len(commits) == 0 {
+		// The user may have no commits while the others have some
+		if len(r.authorFilter) == 0 {
+			return ErrNoCommits
+		}
+		found, err := r.hasCommits(ctx)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrNoCommits
+		}
+	}
 
 	allEmails := getAllEmails(commits)
 	selectedEmails := make(map[string]bool)
@@ -218,6 +545,9 @@ func (r *RepoExtractor) analyseCommits() error {
 	}
 
 	if len(r.UserEmails) == 0 && !r.Headless {
+		if !isTerminal() {
+			return ErrNotInteractive
+		}
 		selectedEmailsWithNames := ui.SelectEmail(allEmails)
 		emails, emailsMap := getEmailsWithoutNames(selectedEmailsWithNames)
 		r.repo.Emails = append(r.repo.Emails, emails...)
@@ -226,89 +556,265 @@ func (r *RepoExtractor) analyseCommits() error {
 		}
 	} else {
 		r.repo.Emails = append(r.repo.Emails, r.UserEmails...)
-		for _, email := range r.UserEmails {
+		// The author emails of the commits are mapped by .mailmap
+		emails, err := r.canonicalEmails(r.UserEmails)
+		if err != nil {
+			return err
+		}
+		emails = append(emails, r.UserEmails...)
+		for _, email := range append(emails, mapEmails(emails, authorMapping)...) {
 			selectedEmails[email] = true
 		}
 	}
 
-	// Only consider commits for user
+	if r.DetectSquashes {
+		r.flagSquashes(commits)
+	}
+
+	// Only consider commits for user, pair programming credits the co-authors too
 	for _, v := range commits {
-		if _, ok := selectedEmails[v.AuthorEmail]; ok {
-			userCommits = append(userCommits, v)
+		if _, ok := selectedEmails[v.AuthorEmail]; !ok && !coAuthoredBy(v, selectedEmails) {
+			continue
+		}
+		if r.SkipDocOnlyCommits && isDocOnly(v) {
+			continue
 		}
+		if r.MinChurn > 0 && churn(v) < r.MinChurn {
+			continue
+		}
+		userCommits = append(userCommits, v)
+	}
+
+	if r.SkipOrphanedCommits {
+		userCommits, err = r.skipOrphanedCommits(userCommits)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The commits of the other authors are needed to tell who was first
+	if len(r.authorFilter) == 0 {
+		r.repo.LanguagesIntroduced = languagesIntroduced(commits, selectedEmails)
+	} else {
+		r.repo.LanguagesIntroduced = []string{}
 	}
+	if r.ComputeOwnership {
+		r.repo.DominatedFiles = dominatedFiles(commits, selectedEmails)
+	}
+	if r.ComputeLeaderboard {
+		r.repo.Leaderboard = leaderboard(commits)
+	}
+
+	err = r.setReferences(userCommits)
+	if err != nil {
+		return err
+	}
+
+	// The patterns match the paths before the obfuscation
+	r.repo.WeightedScore = setWeightedScores(userCommits, r.PathWeights)
 
 	r.userCommits = userCommits
 	return nil
 }
 
-func (r *RepoExtractor) getCommits() ([]*commit.Commit, error) {
+func (r *RepoExtractor) getCommits(ctx context.Context) ([]*commit.Commit, error) {
+	progress := r.progressFunc()
+	if r.LogSource != nil {
+		commits, err := parseGitLog(r.LogSource, r.logger())
+		if err != nil {
+			return nil, err
+		}
+		// The log is in the order of git log, the most recent commits first
+		if r.MaxCommits > 0 && len(commits) > r.MaxCommits {
+			commits = commits[:r.MaxCommits]
+		}
+		progress(len(commits))
+		return commits, nil
+	}
+
+	// Cancelling the context stops the workers and kills their git processes
+	ctx, cancel := context.WithCancel(ctx)
 	jobs := make(chan *req)
+	defer func() {
+		cancel()
+		close(jobs)
+	}()
 	results := make(chan []*commit.Commit)
 	noMoreChan := make(chan bool)
-	for w := 0; w < runtime.NumCPU(); w++ {
-		go func() {
-			err := r.commitWorker(w, jobs, results, noMoreChan)
+	workers := r.workers()
+	errs := make(chan error, workers) // Buffered, so failing workers never block
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			err := r.commitWorker(ctx, w, jobs, results, noMoreChan)
 			if err != nil {
-				fmt.Println("Error during getting commits. Error: " + err.Error())
+				errs <- err
 			}
-		}()
+		}(w)
 	}
 
-	// launch initial jobs
-	lastOffset := 0
-	step := 1000
-	for x := 0; x < runtime.NumCPU(); x++ {
-		jobs <- &req{
-			Limit:  step,
-			Offset: x * step,
+	// sendJob returns the error of a worker if one fails before the job is taken
+	sendJob := func(job *req) error {
+		select {
+		case jobs <- job:
+			return nil
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		lastOffset = step * x
 	}
 
-	var commits []*commit.Commit
-	workersReturnedNoMore := 0
-
 	var pb ui.ProgressBar
-	numberOfCommits := r.getNumberOfCommits()
+	numberOfCommits := r.getNumberOfCommits(ctx)
+	if r.MaxCommits > 0 && numberOfCommits > r.MaxCommits {
+		numberOfCommits = r.MaxCommits
+	}
 	if r.ShowProgressBar && numberOfCommits > 0 {
 		pb = ui.NewProgressBar(numberOfCommits)
 	} else {
 		pb = ui.NilProgressBar()
 	}
-
-	func() {
-		for {
-			select {
-			case res := <-results:
-				lastOffset += step
-				jobs <- &req{
-					Limit:  step,
-					Offset: lastOffset,
-				}
-				commits = append(commits, res...)
-				pb.SetCurrent(len(commits))
-			case <-noMoreChan:
-				workersReturnedNoMore++
-				if workersReturnedNoMore == runtime.NumCPU() {
-					close(jobs)
-					return
-				}
+	defer pb.Finish()
+
+	// Every job gets the next window of commitsPerJob commits. The offset only
+	// moves when a job is sent, so the windows are unique and contiguous.
+	// With MaxCommits no window starts after the cap and the last one ends at it.
+	nextOffset := 0
+	pending := 0 // Jobs sent and not answered yet
+	sendNextJob := func() error {
+		limit := commitsPerJob
+		if r.MaxCommits > 0 {
+			if nextOffset >= r.MaxCommits {
+				return nil
+			}
+			if nextOffset+limit > r.MaxCommits {
+				limit = r.MaxCommits - nextOffset
 			}
 		}
-	}()
-	pb.Finish()
+		err := sendJob(&req{
+			Limit:  limit,
+			Offset: nextOffset,
+		})
+		if err == nil {
+			nextOffset += limit
+			pending++
+		}
+		return err
+	}
 
+	// launch initial jobs
+	for x := 0; x < workers; x++ {
+		err := sendNextJob()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []*commit.Commit
+
+	// Every answer sends the next job until the windows are empty or reach MaxCommits.
+	// The workers waiting for more jobs return when jobs is closed.
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending--
+			err := sendNextJob()
+			if err != nil {
+				return nil, err
+			}
+			commits = append(commits, res...)
+			pb.SetCurrent(len(commits))
+			progress(len(commits))
+		case <-noMoreChan:
+			pending--
+		case err := <-errs:
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	// The workers might have finished while ctx was cancelled
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	return commits, nil
 }
 
+// commitsPerJob is the number of commits read by one git log of the commit workers
+var commitsPerJob = 1000
+
+// progressReportInterval is the number of commits between two lines of the default progress reporting
+const progressReportInterval = 1000
+
+// progressFunc returns ProgressFunc or the default progress reporting.
+// By default progress is printed to stderr, unless the progress bar is shown.
+func (r *RepoExtractor) progressFunc() func(processed int) {
+	if r.ProgressFunc != nil {
+		return r.ProgressFunc
+	}
+	if r.ShowProgressBar {
+		return func(processed int) {}
+	}
+	start := time.Now()
+	lastReported := 0
+	return func(processed int) {
+		if processed-lastReported < progressReportInterval {
+			return
+		}
+		lastReported = processed
+		fmt.Fprintf(os.Stderr, "Processed %d commits in %s\n", processed, time.Since(start).Round(time.Second))
+	}
+}
+
+// ErrNoCommits is returned if the repo has no commits to analyse
+var ErrNoCommits = errors.New("no commits found")
+
+// ErrNotInteractive is returned if the emails should be asked, but there is no terminal to ask them
+var ErrNotInteractive = errors.New("cannot ask for your emails because the terminal is not interactive. Please provide them with --emails (UserEmails)")
+
+// isTerminal is a variable so the tests can replace it
+var isTerminal = ui.IsTerminal
+
+// emailSeparator separates the author name from the email in the email options
+const emailSeparator = " -> "
+
+// noName is shown instead of the author name if it's empty
+const noName = "(no name)"
+
+// churn returns the number of changed lines in the commit
+// Binary files have no line counts, so they don't add to it.
+func churn(c *commit.Commit) int {
+	lines := 0
+	for _, f := range c.ChangedFiles {
+		lines += f.Insertions + f.Deletions
+	}
+	return lines
+}
+
+// isDocOnly returns true if every file changed in the commit is documentation
+func isDocOnly(c *commit.Commit) bool {
+	if len(c.ChangedFiles) == 0 {
+		return false
+	}
+	for _, f := range c.ChangedFiles {
+		if f.Category != categoryDoc {
+			return false
+		}
+	}
+	return true
+}
+
 func getAllEmails(commits []*commit.Commit) []string {
 	allEmails := make([]string, 0, len(commits))
 	emails := make(map[string]bool) // To prevent duplicates
 	for _, v := range commits {
 		if _, ok := emails[v.AuthorEmail]; !ok {
 			emails[v.AuthorEmail] = true
-			allEmails = append(allEmails, fmt.Sprintf("%s -> %s", v.AuthorName, v.AuthorEmail))
+			name := v.AuthorName
+			if strings.TrimSpace(name) == "" {
+				name = noName
+			}
+			allEmails = append(allEmails, name+emailSeparator+v.AuthorEmail)
 		}
 	}
 	return allEmails
@@ -316,169 +822,164 @@ func getAllEmails(commits []*commit.Commit) []string {
 
 func getEmailsWithoutNames(emails []string) ([]string, map[string]bool) {
 	emailsWithoutNames := make(map[string]bool, len(emails))
-	emailsWithoutNamesArray := make([]string, len(emails))
-	for i, selectedEmail := range emails {
-		fields := strings.Split(selectedEmail, " -> ")
-		// TODO handle authorName being empty
-		if len(fields) > 0 {
-			emailsWithoutNames[fields[1]] = true
-			emailsWithoutNamesArray[i] = fields[1]
+	emailsWithoutNamesArray := make([]string, 0, len(emails))
+	for _, selectedEmail := range emails {
+		// Names can contain the separator too, but emails can't
+		i := strings.LastIndex(selectedEmail, emailSeparator)
+		if i == -1 {
+			continue
 		}
+		email := selectedEmail[i+len(emailSeparator):]
+		emailsWithoutNames[email] = true
+		emailsWithoutNamesArray = append(emailsWithoutNamesArray, email)
 	}
 	return emailsWithoutNamesArray, emailsWithoutNames
 }
 
-func (r *RepoExtractor) getNumberOfCommits() int {
-	cmd := exec.Command(r.GitPath,
-		"--no-pager",
-		"log",
-		"--all",
-		"--no-merges",
-		"--pretty=oneline",
-	)
-	cmd.Dir = r.RepoPath
-	stdout, err := cmd.CombinedOutput()
+func (r *RepoExtractor) getNumberOfCommits(ctx context.Context) int {
+	args := append([]string{"--no-pager", "log", "--no-merges", "--pretty=oneline"}, r.authorArgs()...)
+	args = append(args, r.revisionArgs()...)
+	stdout, err := r.runGitContext(ctx, append(args, "--")...)
 	if err != nil {
-		fmt.Println("Cannot get number of commits. Cannot show progress bar. Error: " + err.Error())
+		r.logf("Cannot get number of commits. Cannot show progress bar. Error: %s", err.Error())
 		return 0
 	}
 	return strings.Count(string(stdout), "\n")
 }
 
 // commitWorker get commits from git
-func (r *RepoExtractor) commitWorker(w int, jobs <-chan *req, results chan<- []*commit.Commit, noMoreChan chan<- bool) error {
+// It stops without an error when ctx is cancelled.
+func (r *RepoExtractor) commitWorker(ctx context.Context, w int, jobs <-chan *req, results chan<- []*commit.Commit, noMoreChan chan<- bool) error {
 	for v := range jobs {
-		var commits []*commit.Commit
-
-		cmd := exec.Command(r.GitPath,
+		// core.quotePath=false keeps non-ASCII paths unescaped, see unquotePath for the rest
+		args := append([]string{
+			"-c", "core.quotePath=false",
 			"log",
 			"--numstat",
-			"--all",
+			"--summary",
 			fmt.Sprintf("--skip=%d", v.Offset),
 			fmt.Sprintf("--max-count=%d", v.Limit),
-			"--pretty=format:|||BEGIN|||%H|||SEP|||%an|||SEP|||%ae|||SEP|||%ad",
+			r.prettyFormat(),
 			"--no-merges",
-		)
-		cmd.Dir = r.RepoPath
-		stdout, err := cmd.StdoutPipe()
-		if nil != err {
-			fmt.Println("Cannot create pipe.")
+		}, r.authorArgs()...)
+		args = append(args, r.revisionArgs()...)
+		// The revisions come last, "--" tells them from paths
+		logCtx, cancel := r.withCommandTimeout(ctx)
+		commits, err := r.readLog(logCtx, append(args, "--")...)
+		timedOut := logCtx.Err() == context.DeadlineExceeded
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// The commits can't be skipped, the incremental cursor would move past them
+			if timedOut {
+				return fmt.Errorf("git log of the commits %d to %d took longer than %s: %w", v.Offset, v.Offset+v.Limit, r.commandTimeout(), err)
+			}
 			return err
 		}
-		if err := cmd.Start(); err != nil {
-			fmt.Println("Error during execution of Git command.")
-			return err
+		r.debugf("Worker %d read %d commits from %d", w, len(commits), v.Offset)
+		for _, c := range commits {
+			r.debugf("Read commit %s by %s changing %d files", c.Hash, c.AuthorEmail, len(c.ChangedFiles))
 		}
 
-		// parse the output into stats
-		scanner := bufio.NewScanner(stdout)
-		currentLine := 0
-		var currectCommit *commit.Commit
-		for scanner.Scan() {
-			m := scanner.Text()
-			currentLine++
-			if m == "" {
-				continue
-			}
-			if strings.HasPrefix(m, "|||BEGIN|||") {
-				// we reached a new commit
-				// save the existing
-				if currectCommit != nil {
-					commits = append(commits, currectCommit)
-				}
-
-				// and add new one commit
-				m = strings.Replace(m, "|||BEGIN|||", "", 1)
-				bits := strings.Split(m, "|||SEP|||")
-				changedFiles := []*commit.ChangedFile{}
-				dateStr := ""
-				t, err := time.Parse("Mon Jan 2 15:04:05 2006 -0700", bits[3])
-				if err == nil {
-					dateStr = t.Format("2006-01-02 15:04:05 -0700")
-				} else {
-					fmt.Println("Cannot convert date. Expected date format: Mon Jan 2 15:04:05 2006 -0700. Got: " + bits[3])
-				}
-				currectCommit = &commit.Commit{
-					Hash:         bits[0],
-					AuthorName:   bits[1],
-					AuthorEmail:  bits[2],
-					Date:         dateStr,
-					ChangedFiles: changedFiles,
-				}
-				continue
-			}
-
-			bits := strings.Fields(m)
-
-			insertionsString := bits[0]
-			if insertionsString == "-" {
-				insertionsString = "0"
-			}
-			insertions, err := strconv.Atoi(insertionsString)
-			if err != nil {
-				fmt.Println("Cannot convert the following into integer: " + insertionsString)
-				return err
-			}
-
-			deletionsString := bits[1]
-			if deletionsString == "-" {
-				deletionsString = "0"
-			}
-			deletions, err := strconv.Atoi(deletionsString)
-			if err != nil {
-				fmt.Println("Cannot convert the following into integer: " + deletionsString)
-				return err
-			}
-
-			fileName := bits[2]
-			// it is a rename, skip
-			if strings.Contains("=>", fileName) {
-				continue
+		if len(commits) == 0 {
+			select {
+			case noMoreChan <- true:
+			case <-ctx.Done():
 			}
+			return nil
+		}
+		select {
+		case results <- commits:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
 
-			changedFile := &commit.ChangedFile{
-				Path:       bits[2],
-				Insertions: insertions,
-				Deletions:  deletions,
-			}
+// dateFormat is the format of the commit dates in the output
+const dateFormat = "2006-01-02 15:04:05 -0700"
 
-			if currectCommit == nil {
-				// TODO maybe skip? does this break anything?
-				return errors.New("did not expect current commit to be null")
-			}
+// logFormat is the --pretty format understood by parseGitLog, see logHeaderFormats
+// The body can span multiple lines, so the header ends with an explicit marker.
+// The fields are separated by NUL, which commit messages can't contain.
+const logFormat = "%x1e%H%x00%an%x00%ae%x00%ad%x00%s%x00%b%x00%cd%x00%x1e"
 
-			if currectCommit.ChangedFiles == nil {
-				// TODO maybe skip? does this break anything?
-				return errors.New("did not expect current commit changed files to be null")
-			}
+// mailmapLogFormat is logFormat with the author names and emails mapped by .mailmap
+const mailmapLogFormat = "%x1e%H%x00%aN%x00%aE%x00%ad%x00%s%x00%b%x00%cd%x00%x1e"
 
-			currectCommit.ChangedFiles = append(currectCommit.ChangedFiles, changedFile)
-		}
+// prettyFormat returns the --pretty format of git log
+func (r *RepoExtractor) prettyFormat() string {
+	if r.SkipMailmap {
+		return "--pretty=format:" + logFormat
+	}
+	return "--pretty=format:" + mailmapLogFormat
+}
 
-		// last commit will not get appended otherwise
-		// because scanner is not returning anything
-		if currectCommit != nil {
-			commits = append(commits, currectCommit)
+// canonicalEmails maps the emails with .mailmap, like the author emails of the commits
+func (r *RepoExtractor) canonicalEmails(emails []string) ([]string, error) {
+	// Commits from LogSource can't be mapped, there might be no repo at all
+	if r.SkipMailmap || r.LogSource != nil || len(emails) == 0 {
+		return emails, nil
+	}
+	args := []string{"check-mailmap"}
+	for _, email := range emails {
+		args = append(args, "<"+email+">")
+	}
+	out, err := r.runGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot map the emails with .mailmap: %w", err)
+	}
+	canonical := make([]string, 0, len(emails))
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		start := strings.LastIndex(line, "<")
+		end := strings.LastIndex(line, ">")
+		if start != -1 && end > start {
+			canonical = append(canonical, line[start+1:end])
 		}
+	}
+	return canonical, nil
+}
 
-		if len(commits) == 0 {
-			noMoreChan <- true
-			return nil
-		}
-		results <- commits
+// readLog runs git log with args using logFormat and parses its output
+func (r *RepoExtractor) readLog(ctx context.Context, args ...string) ([]*commit.Commit, error) {
+	stdout, err := r.streamGitContext(ctx, args...)
+	if err != nil {
+		r.logf("Error during execution of Git command.")
+		return nil, err
 	}
-	return nil
+	commits, err := parseGitLog(stdout, r.logger())
+	if err != nil {
+		stdout.Close()
+		return nil, err
+	}
+	err = stdout.Close()
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
 }
 
 // TODO This is not ready yet (can't find libraries based on language -> look at libraryWorker)
-func (r *RepoExtractor) analyseLibraries() error {
-	fmt.Println("Analysing libraries")
+func (r *RepoExtractor) analyseLibraries(ctx context.Context) error {
+	r.logf("Analysing libraries")
 
 	jobs := make(chan *commit.Commit, len(r.userCommits))
 	results := make(chan bool, len(r.userCommits))
+	workers := r.workers()
+	errs := make(chan error, workers) // Buffered, so failing workers never block
+	// Shared by the workers, identical files in different commits are analysed once
+	cache := newLibraryCache(blobCacheSize)
 	// Analyse libraries for every commit
-	for w := 1; w <= runtime.NumCPU(); w++ {
-		go r.libraryWorker(jobs, results)
+	for w := 1; w <= workers; w++ {
+		go func() {
+			err := r.libraryWorker(ctx, jobs, results, cache)
+			if err != nil {
+				errs <- err
+			}
+		}()
 	}
 	for _, v := range r.userCommits {
 		jobs <- v
@@ -490,79 +991,149 @@ func (r *RepoExtractor) analyseLibraries() error {
 	} else {
 		pb = ui.NilProgressBar()
 	}
+	defer pb.Finish()
 	for a := 1; a <= len(r.userCommits); a++ {
-		<-results
-		pb.Inc()
+		select {
+		case <-results:
+			pb.Inc()
+		case err := <-errs:
+			// The blob readers fail when ctx kills them
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	pb.Finish()
 	return nil
 }
 
-func (r *RepoExtractor) libraryWorker(commits <-chan *commit.Commit, results chan<- bool) error {
+// DefaultMaxFileBytes is used if MaxFileBytes is zero
+const DefaultMaxFileBytes = 1024 * 1024
+
+// maxFileBytes returns the size limit of the files read by the library detection, zero means no limit
+func (r *RepoExtractor) maxFileBytes() int {
+	if r.MaxFileBytes == 0 {
+		return DefaultMaxFileBytes
+	}
+	if r.MaxFileBytes < 0 {
+		return 0
+	}
+	return r.MaxFileBytes
+}
+
+// DefaultCommandTimeout is used if CommandTimeout is zero
+const DefaultCommandTimeout = 60 * time.Second
+
+// commandTimeout returns the time limit of the git commands of the workers, zero means no limit
+func (r *RepoExtractor) commandTimeout() time.Duration {
+	if r.CommandTimeout == 0 {
+		return DefaultCommandTimeout
+	}
+	if r.CommandTimeout < 0 {
+		return 0
+	}
+	return r.CommandTimeout
+}
+
+// withCommandTimeout returns a context of ctx which is done after commandTimeout
+func (r *RepoExtractor) withCommandTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := r.commandTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// startBlobReader starts the git cat-file --batch of a library worker
+// It is killed when ctx is done or when reading a file takes longer than commandTimeout.
+func (r *RepoExtractor) startBlobReader(ctx context.Context) (*blobReader, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	blobs, err := newBlobReader(r.gitCommandContext(ctx, "cat-file", "--batch"))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	blobs.maxSize = r.maxFileBytes()
+	blobs.timeout = r.commandTimeout()
+	blobs.kill = cancel
+	return blobs, nil
+}
+
+func (r *RepoExtractor) libraryWorker(ctx context.Context, commits <-chan *commit.Commit, results chan<- bool, cache *libraryCache) error {
 	languageAnalyzer := languagedetection.NewLanguageAnalyzer()
+	blobs, err := r.startBlobReader(ctx)
+	if err != nil {
+		return err
+	}
+	// The reader is replaced after a timeout
+	defer func() {
+		if blobs != nil {
+			blobs.Close()
+		}
+	}()
 	for commit := range commits {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.debugf("Detecting the libraries of commit %s", commit.Hash)
 		libraries := map[string][]string{}
 		for n, fileChange := range commit.ChangedFiles {
-
-			lang := ""
-			fileContents := make([]byte, 0)
-
-			extension := filepath.Ext(fileChange.Path)
-			if extension == "" {
+			// Generated and vendored files don't count toward the language stats, binary files have no language
+			if fileChange.IsBinary || r.isLinguistExcluded(fileChange.Path) {
 				continue
 			}
-			// remove the trailing dot
-			extension = extension[1:]
-
-			cmd := exec.Command(r.GitPath,
-				"--no-pager",
-				"show",
-				fmt.Sprintf("%s:%s", commit.Hash, fileChange.Path),
-			)
-			cmd.Dir = r.RepoPath
-			var err error
-			fileContents, err = cmd.CombinedOutput()
-			if err != nil {
-				searchString1 := fmt.Sprintf("Path '%s' does not exist in '%s'", fileChange.Path, commit.Hash)
-				searchString2 := fmt.Sprintf("Path '%s' exists on disk, but not in '%s'", fileChange.Path, commit.Hash)
-				// Ignore case is needed because on windows error message starts with lowercase letter, in other systems it starts with uppercase letter
-				stringSearcher := search.New(language.English, search.IgnoreCase)
-				// means the file was deleted, skip
-				start, end := stringSearcher.IndexString(string(fileContents), searchString1)
-				if start != -1 && end != -1 {
-					continue
-				}
-				start, end = stringSearcher.IndexString(string(fileContents), searchString2)
-				if start != -1 && end != -1 {
-					continue
-				}
-				return err
-			}
-			if languageAnalyzer.ShouldUseFile(extension) {
-				lang = languageAnalyzer.DetectLanguageFromFile(fileChange.Path, fileContents)
-			} else {
-				lang = languageAnalyzer.DetectLanguageFromExtension(extension)
+			// Deleted files have no contents in the commit, git isn't asked for them
+			if isDeleted(fileChange) {
+				r.debugf("Skipping %s of %s, it was deleted", fileChange.Path, commit.Hash)
+				continue
 			}
 
-			// We don't know extension, nothing to do
-			if lang == "" {
+			// The manifests and lockfiles of the file analyzers are read whatever their size
+			maxSize := blobs.maxSize
+			if _, _, err := librarydetection.GetFileAnalyzer(filepath.Base(fileChange.Path)); err == nil {
+				maxSize = 0
+			}
+			oid, fileContents, found, err := blobs.readBlob(commit.Hash, fileChange.Path, maxSize)
+			if err == errBlobTooLarge {
+				r.debugf("Skipping the libraries of %s of %s, it is larger than %d bytes", fileChange.Path, commit.Hash, maxSize)
+				commit.ChangedFiles[n].Language = languageFromName(languageAnalyzer, fileChange.Path)
+				continue
+			}
+			if err == errBlobTimeout && ctx.Err() == nil {
+				r.logf("Skipping %s of %s, git cat-file took longer than %s", fileChange.Path, commit.Hash, blobs.timeout)
+				blobs.Close()
+				blobs, err = r.startBlobReader(ctx)
+				if err != nil {
+					return err
+				}
 				continue
 			}
-
-			commit.ChangedFiles[n].Language = lang
-			analyzer, err := librarydetection.GetAnalyzer(lang)
 			if err != nil {
+				return err
+			}
+			// The deletions aren't known without --summary, e.g. in a LogSource
+			if !found {
 				continue
 			}
 
-			fileLibraries, err := analyzer.ExtractLibraries(string(fileContents))
-			if err != nil {
-				fmt.Printf("error extracting libraries for %s: %s \n", lang, err.Error())
+			key := libraryCacheKey(oid, fileChange.Path)
+			detected, ok := cache.get(key)
+			if !ok {
+				detected = r.detectLibraries(languageAnalyzer, fileChange.Path, fileContents)
+				cache.add(key, detected)
+			}
+			if detected.language != "" {
+				r.debugf("Language of %s is %s", fileChange.Path, detected.language)
+				commit.ChangedFiles[n].Language = detected.language
 			}
-			if libraries[lang] == nil {
-				libraries[lang] = make([]string, 0)
+			// The cached slices are shared, so they are copied
+			for lang, fileLibraries := range detected.libraries {
+				if libraries[lang] == nil && fileLibraries != nil {
+					libraries[lang] = make([]string, 0, len(fileLibraries))
+				}
+				libraries[lang] = append(libraries[lang], fileLibraries...)
 			}
-			libraries[lang] = append(libraries[lang], fileLibraries...)
 		}
 		commit.Libraries = libraries
 		results <- true
@@ -570,83 +1141,357 @@ func (r *RepoExtractor) libraryWorker(commits <-chan *commit.Commit, results cha
 	return nil
 }
 
+// isDeleted reports whether the commit deleted the file, from the --summary of git log
+func isDeleted(f *commit.ChangedFile) bool {
+	return f.ChangeType == commit.ChangeDeleted
+}
+
+// languageFromName detects the language of a file without its contents
+// The extensions of several languages, like .m, are left unknown.
+func languageFromName(languageAnalyzer *languagedetection.LanguageAnalyzer, path string) string {
+	lang := languageAnalyzer.DetectLanguageFromFileName(filepath.Base(path))
+	if lang != "" {
+		return lang
+	}
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if extension == "" || languageAnalyzer.ShouldUseFile(extension) {
+		return ""
+	}
+	return languageAnalyzer.DetectLanguageFromExtension(extension)
+}
+
+// dependsOnTypeScript tells the npm manifests and lockfiles of TypeScript projects by their typescript dependency
+// The lockfiles name the libraries with their versions, like typescript@4.1.3.
+func dependsOnTypeScript(libraries []string) bool {
+	for _, library := range libraries {
+		if library == "typescript" || strings.HasPrefix(library, "typescript@") {
+			return true
+		}
+	}
+	return false
+}
+
+// detectLibraries detects the language and the libraries of a file
+func (r *RepoExtractor) detectLibraries(languageAnalyzer *languagedetection.LanguageAnalyzer, path string, fileContents []byte) detectedFile {
+	detected := detectedFile{libraries: map[string][]string{}}
+
+	// Manifests like Package.swift have their own analyzers
+	fileName := filepath.Base(path)
+	fileAnalyzer, fileAnalyzerLang, fileAnalyzerErr := librarydetection.GetFileAnalyzer(fileName)
+	if fileAnalyzerErr == nil {
+		fileLibraries, err := fileAnalyzer.ExtractLibraries(string(fileContents))
+		if err != nil {
+			r.logf("error extracting libraries from %s: %s", path, err.Error())
+		}
+		if fileAnalyzerLang == "JavaScript" && dependsOnTypeScript(fileLibraries) {
+			fileAnalyzerLang = "TypeScript"
+		}
+		detected.libraries[fileAnalyzerLang] = append(detected.libraries[fileAnalyzerLang], fileLibraries...)
+	}
+
+	// Well-known files like Dockerfile, then scripts without an extension by their shebang
+	extension := filepath.Ext(path)
+	lang := languageAnalyzer.DetectLanguageFromFileName(fileName)
+	if lang == "" && extension == "" {
+		lang = languageAnalyzer.DetectLanguageFromShebang(fileContents)
+	}
+	if lang == "" && extension != "" {
+		// remove the trailing dot, the map has lowercase extensions only
+		extension = strings.ToLower(extension[1:])
+		if languageAnalyzer.ShouldUseFile(extension) {
+			lang = languageAnalyzer.DetectLanguageFromFile(path, fileContents)
+		} else {
+			lang = languageAnalyzer.DetectLanguageFromExtension(extension)
+		}
+	}
+
+	// We don't know extension, nothing to do
+	if lang == "" {
+		return detected
+	}
+
+	detected.language = lang
+	analyzer, err := librarydetection.GetAnalyzer(lang)
+	if err != nil {
+		return detected
+	}
+
+	fileLibraries, err := analyzer.ExtractLibraries(string(fileContents))
+	if err != nil {
+		r.logf("error extracting libraries for %s: %s", lang, err.Error())
+	}
+	if detected.libraries[lang] == nil {
+		detected.libraries[lang] = make([]string, 0)
+	}
+	detected.libraries[lang] = append(detected.libraries[lang], fileLibraries...)
+	return detected
+}
+
 // Obfuscate the result
 func (r *RepoExtractor) obfuscate() {
 	for _, commit := range r.userCommits {
 		commit = obfuscation.Obfuscate(commit)
 	}
+	for i, file := range r.repo.DominatedFiles {
+		r.repo.DominatedFiles[i] = obfuscation.ObfuscatePath(file)
+	}
+	r.repo.PrimaryRemoteURL = ""
+	for i := range r.repo.Leaderboard {
+		r.repo.Leaderboard[i].Name = obfuscation.ObfuscateText(r.repo.Leaderboard[i].Name)
+		r.repo.Leaderboard[i].Email = obfuscation.ObfuscateText(r.repo.Leaderboard[i].Email)
+	}
 }
 
 // Writes result to the file
 func (r *RepoExtractor) export() error {
-	fmt.Println("Creating output file")
+	r.logf("Creating output file")
 
-	repoDataPath := r.OutputPath + "_v2.json"
-	zipPath := r.OutputPath + "_v2.json.zip"
-	// Remove old files
-	os.Remove(repoDataPath)
-	os.Remove(zipPath)
+	outputDir := filepath.Dir(r.outputFilePath())
+	err := os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return fmt.Errorf("cannot create output directory %s: %w", outputDir, err)
+	}
 
-	// Only do this when not using default value
-	if r.OutputPath != "./repo_data_v2" {
-		err := os.MkdirAll(r.OutputPath, 0755)
+	switch r.OutputFormat {
+	case "", FormatZip:
+		return r.exportZip(outputDir)
+	case FormatGzip, FormatJSONL:
+		if r.OutputLayout != "" && r.OutputLayout != LayoutSingle {
+			return fmt.Errorf("the %s output layout needs the %s output format", r.OutputLayout, FormatZip)
+		}
+		if r.OutputFormat == FormatGzip {
+			return r.exportGzip()
+		}
+		return r.exportJSONL()
+	default:
+		return fmt.Errorf("unknown output format %s", r.OutputFormat)
+	}
+}
+
+// exportZip archives the output into zipPath
+// The archive is created next to the old zip and replaces it when it's complete, so a failure leaves no partial zip.
+func (r *RepoExtractor) exportZip(outputDir string) error {
+	repoDataPath := r.repoDataPath()
+	zipPath := r.zipPath()
+	// archiver refuses to overwrite an existing file and needs the .zip extension
+	archivePath := strings.TrimSuffix(zipPath, ".zip") + ".tmp.zip"
+	err := removeFile(archivePath)
+	if err != nil {
+		return fmt.Errorf("cannot remove old output file %s: %w", archivePath, err)
+	}
+
+	var sources []string
+	switch r.OutputLayout {
+	case "", LayoutSingle:
+		err = r.writeRepoData(repoDataPath)
 		if err != nil {
-			log.Println("Cannot create directory. Error:", err.Error())
+			return err
+		}
+		sources = []string{repoDataPath}
+		// We don't need this because we already have zip file
+		if !r.KeepRawData {
+			defer os.Remove(repoDataPath)
 		}
+	case LayoutPerLanguage:
+		dir, err := r.languagesDir(outputDir)
+		if err != nil {
+			return err
+		}
+		if !r.KeepRawData {
+			defer os.RemoveAll(dir)
+		}
+		sources, err = r.writePerLanguage(dir)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output layout %s", r.OutputLayout)
 	}
 
-	file, err := os.Create(repoDataPath)
+	if r.Deterministic {
+		err = setDeterministicModTimes(sources)
+		if err != nil {
+			return err
+		}
+	}
+	err = archive(sources, archivePath)
 	if err != nil {
+		os.Remove(archivePath)
 		return err
 	}
+	err = os.Rename(archivePath, zipPath)
+	if err != nil {
+		os.Remove(archivePath)
+		return fmt.Errorf("cannot replace old output file %s: %w", zipPath, err)
+	}
+	return nil
+}
 
-	w := bufio.NewWriter(file)
-	if r.OverwrittenRepoName != "" {
-		r.repo.RepoName = r.OverwrittenRepoName
+// archive creates the zip, it is replaced by the tests
+var archive = archiver.Archive
+
+// writeRepoData writes the repo metadata and the user's commits to path, one JSON per line
+func (r *RepoExtractor) writeRepoData(path string) error {
+	return replaceFile(path, r.writeRepoDataTo)
+}
+
+// writeRepoDataTo writes the metadata line and the commit lines
+func (r *RepoExtractor) writeRepoDataTo(writer io.Writer) error {
+	data, err := r.Results()
+	if err != nil {
+		return err
 	}
-	repoMetaData, err := json.Marshal(r.repo)
+	w := bufio.NewWriter(writer)
+	repoMetaData, err := json.Marshal(data.Metadata)
 	if err != nil {
 		return err
 	}
 	fmt.Fprintln(w, string(repoMetaData))
 
-	for _, commit := range r.userCommits {
+	for _, commit := range data.Commits {
 		commitData, err := json.Marshal(commit)
 		if err != nil {
-			fmt.Printf("Couldn't write commit to file. CommitHash: %s Error: %s", commit.Hash, err.Error())
+			r.logf("Couldn't write commit to file. CommitHash: %s Error: %s", commit.Hash, err.Error())
 			continue
 		}
 		fmt.Fprintln(w, string(commitData))
 	}
-	w.Flush() // important
-	file.Close()
+	return w.Flush() // important
+}
+
+// DefaultOutputPath is used if OutputPath is empty
+const DefaultOutputPath = "./repo_data_v2"
 
-	err = archiver.Archive([]string{repoDataPath}, zipPath)
+func (r *RepoExtractor) outputPath() string {
+	if r.OutputPath == "" {
+		return DefaultOutputPath
+	}
+	return r.OutputPath
+}
+
+// repoDataPath is the uncompressed output file
+func (r *RepoExtractor) repoDataPath() string {
+	return r.outputPath() + "_v2.json"
+}
+
+// languagesDir creates the directory of the per language files
+// It is temporary unless KeepRawData is set.
+func (r *RepoExtractor) languagesDir(outputDir string) (string, error) {
+	if !r.KeepRawData {
+		return ioutil.TempDir(outputDir, ".repo_data_languages")
+	}
+	dir := r.outputPath() + "_v2_languages"
+	err := os.RemoveAll(dir)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("cannot remove old output directory %s: %w", dir, err)
 	}
+	return dir, os.Mkdir(dir, 0755)
+}
 
-	// We don't need this because we already have zip file
-	os.Remove(repoDataPath)
+// zipPath is the final output file
+func (r *RepoExtractor) zipPath() string {
+	return r.outputPath() + "_v2.json.zip"
+}
+
+// removeFile removes the file in path. It's not an error if the file doesn't exist.
+func removeFile(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return nil
 }
 
+// shouldUpload reports whether the results are uploaded after the export
+// By default only the users running this script locally upload them.
+func (r *RepoExtractor) shouldUpload() bool {
+	if r.NoUpload {
+		return false
+	}
+	return r.Upload || !r.Headless
+}
+
+// askUpload asks the user to confirm the upload to the URL, it is replaced by the tests
+var askUpload = ui.ConfirmUpload
+
+// confirmUpload reports whether the user agreed to upload the results
+// The interactive runs ask the user unless AssumeYes is set, without a terminal nothing is uploaded.
+func (r *RepoExtractor) confirmUpload() bool {
+	if r.Headless || r.AssumeYes {
+		return true
+	}
+	if !isTerminal() {
+		r.logf("Cannot ask whether to upload the results without a terminal. Set AssumeYes to upload them.")
+		return false
+	}
+	return askUpload(r.uploadURL())
+}
+
+// uploadURL returns the endpoint of the upload
+func (r *RepoExtractor) uploadURL() string {
+	if r.UploadURL == "" {
+		return DefaultUploadURL
+	}
+	return r.UploadURL
+}
+
 // This is for repo_info_extractor used locally and for user to
 // upload his/her results automatically to the codersrank
 func (r *RepoExtractor) upload() error {
-	fmt.Println("Uploading result to CodersRank")
-	url, err := Upload(r.OutputPath+"_v2.json.zip", r.repo.RepoName)
+	r.logf("Uploading result to CodersRank")
+	uploadURL := r.uploadURL()
+	token := r.UploadToken
+	if token == "" {
+		token = os.Getenv("CODERSRANK_TOKEN")
+	}
+	attempts := r.UploadAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	delay := r.UploadRetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	url, err := r.uploadWithRetry(uploadURL, token, r.outputFilePath(), r.repo.RepoName, attempts, delay)
 	if err != nil {
 		return err
 	}
-	fmt.Println("Go to this link in the browser =>", url)
+	r.logf("Go to this link in the browser => %s", url)
 	return nil
 }
 
-type repo struct {
-	RepoName        string   `json:"repo"`
-	Emails          []string `json:"emails"`
-	SuggestedEmails []string `json:"suggestedEmails"`
+// SchemaVersion is the version of the output format
+// Bump it whenever the shape of the output changes.
+const SchemaVersion = 2
+
+// RepoMetadata is the first line of the output, the repo level data of the extraction
+type RepoMetadata struct {
+	SchemaVersion        int                     `json:"schemaVersion"`
+	ExtractorVersion     string                  `json:"extractorVersion"`
+	RepoName             string                  `json:"repo"`
+	PrimaryRemoteURL     string                  `json:"primaryRemoteUrl"` // Empty if the repo has no remote
+	Provider             string                  `json:"provider"`         // One of the Provider constants, empty if the repo has no remote
+	DefaultBranch        string                  `json:"defaultBranch"`    // Empty if it is unknown
+	Emails               []string                `json:"emails"`
+	SuggestedEmails      []string                `json:"suggestedEmails"`
+	TopDirectories       []DirCount              `json:"topDirectories"`
+	FirstCommitAt        string                  `json:"firstCommitAt,omitempty"` // Date of the user's earliest commit, omitted without commits
+	LastCommitAt         string                  `json:"lastCommitAt,omitempty"`  // Date of the user's latest commit, omitted without commits
+	AverageCommitGap     int64                   `json:"averageCommitGap"`        // In seconds
+	MedianCommitGap      int64                   `json:"medianCommitGap"`         // In seconds
+	WeeklyActivity       []WeekStat              `json:"weeklyActivity"`
+	TimeZones            []TimeZoneCount         `json:"timeZones"`
+	TimeZoneSource       string                  `json:"timeZoneSource"`           // Date of the commits counted in TimeZones
+	LanguageAddDelete    map[string]AddDelete    `json:"languageAddDelete"`        // Lines inserted and deleted by the user by language
+	LanguageSummary      map[string]LanguageStat `json:"languageSummary"`          // Commits and lines of the user by language, see UnknownLanguage
+	DominatedFiles       []string                `json:"dominatedFiles,omitempty"` // Files where the user changed the most lines
+	LanguagesIntroduced  []string                `json:"languagesIntroduced"`      // Languages which the user committed before anybody else
+	AuthorStats          map[string]AuthorStats  `json:"authorStats"`              // Commits and lines of the user by author email
+	PrimaryLanguages     []string                `json:"primaryLanguages"`         // Languages where the user changed the most lines, see PrimaryLanguages
+	OrphanedCommits      int                     `json:"orphanedCommits"`          // Commits of the user left out by SkipOrphanedCommits
+	MaxLanguagesInCommit int                     `json:"maxLanguagesInCommit"`
+	WeightedScore        float64                 `json:"weightedScore"` // Sum of the weighted scores of the commits
+	Leaderboard          []ContributorStat       `json:"leaderboard,omitempty"`
 }
 
 type req struct {
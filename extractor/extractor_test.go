@@ -1,9 +1,22 @@
 package extractor_test
 
 import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 
+	"github.com/codersrank-org/repo_info_extractor/commit"
 	"github.com/codersrank-org/repo_info_extractor/extractor"
 )
 
@@ -34,3 +47,1450 @@ var _ = Describe("GetRepoName", func() {
 		})
 	})
 })
+
+var _ = Describe("Repo initialization", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	newExtractor := func() *extractor.RepoExtractor {
+		return &extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			SkipLibraries: true,
+			UserEmails:    []string{"test@example.com"},
+		}
+	}
+
+	It("should return a clear error for an empty repo", func() {
+		re := newExtractor()
+		re.Headless = true
+		Expect(re.Extract()).To(MatchError(extractor.ErrNoCommits))
+	})
+
+	It("should not prompt for the emails of an empty repo", func() {
+		defer extractor.SetIsTerminal(func() bool { return true })()
+		re := newExtractor()
+		re.UserEmails = nil
+		Expect(re.Extract()).To(MatchError(extractor.ErrNoCommits))
+	})
+
+	It("should use the directory name without a remote", func() {
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		metadata, _ := extractRepo(repo, skipLibraries)
+		Expect(metadata["repo"]).To(Equal(filepath.Base(repo.Path)))
+		Expect(metadata["primaryRemoteUrl"]).To(BeEmpty())
+		Expect(metadata["provider"]).To(BeEmpty())
+	})
+
+	It("should record the remote", func() {
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		repo.git("remote", "add", "origin", "https://github.com/codersrank-org/repo_info_extractor.git")
+		metadata, _ := extractRepo(repo, skipLibraries)
+		Expect(metadata["repo"]).To(Equal("codersrank-org/repo_info_extractor"))
+		Expect(metadata["primaryRemoteUrl"]).To(Equal("https://github.com/codersrank-org/repo_info_extractor.git"))
+		Expect(metadata["provider"]).To(Equal("github"))
+	})
+
+	Context("without origin", func() {
+		repoName := func(remoteName string) string {
+			metadata, _ := extractRepo(repo, func(re *extractor.RepoExtractor) {
+				re.SkipLibraries = true
+				re.RemoteName = remoteName
+			})
+			return metadata["repo"].(string)
+		}
+
+		BeforeEach(func() {
+			repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+			repo.git("remote", "add", "upstream", "https://github.com/codersrank-org/upstream.git")
+		})
+
+		It("should use the only remote", func() {
+			Expect(repoName("")).To(Equal("codersrank-org/upstream"))
+		})
+
+		It("should use the first remote in headless mode", func() {
+			repo.git("remote", "add", "fork", "https://github.com/someone/fork.git")
+			Expect(repoName("")).To(Equal("someone/fork"))
+		})
+
+		It("should ask the user to choose in interactive mode", func() {
+			repo.git("remote", "add", "fork", "https://github.com/someone/fork.git")
+			defer extractor.SetIsTerminal(func() bool { return true })()
+			var options []string
+			defer extractor.SetSelectRemote(func(remotes []string) string {
+				options = remotes
+				return "upstream"
+			})()
+			// Interactive extractions upload the results, so only the remote is chosen
+			re := newExtractor()
+			Expect(re.PrimaryRemoteURL()).To(Equal("https://github.com/codersrank-org/upstream.git"))
+			Expect(options).To(Equal([]string{"fork", "upstream"}))
+		})
+
+		It("should use RemoteName", func() {
+			repo.git("remote", "add", "fork", "https://github.com/someone/fork.git")
+			Expect(repoName("upstream")).To(Equal("codersrank-org/upstream"))
+		})
+	})
+})
+
+var _ = Describe("ExtractRange", func() {
+	var repo *testRepo
+	var hashes []string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		hashes = []string{
+			repo.commit(testCommit{Files: map[string]string{"a.go": "package a\n"}}),
+			repo.commit(testCommit{Files: map[string]string{"b.go": "package b\n"}}),
+			repo.commit(testCommit{Files: map[string]string{"c.go": "package c\n"}, Email: "other@example.com"}),
+		}
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should return only the commits in the range", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(hashes[0], hashes[2])
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits).To(HaveLen(2))
+		Expect(result.Commits[0].Hash).To(Equal(hashes[2]))
+		Expect(result.Commits[0].AuthorEmail).To(Equal("other@example.com"))
+		Expect(result.Commits[0].ChangedFiles[0].Path).To(Equal("c.go"))
+		Expect(result.Commits[1].Hash).To(Equal(hashes[1]))
+		Expect(result.Metadata).To(BeNil())
+	})
+
+	It("should fail for an unknown endpoint", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		_, err := re.ExtractRange(hashes[0], "does-not-exist")
+		Expect(err).Should(MatchError("does-not-exist is not a valid commit"))
+	})
+})
+
+var _ = Describe("Export", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	newExtractor := func() *extractor.RepoExtractor {
+		return &extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			Headless:      true,
+			SkipLibraries: true,
+			UserEmails:    []string{"test@example.com"},
+		}
+	}
+
+	It("should replace an existing zip", func() {
+		zipPath := filepath.Join(outputDir, "repo_data_v2.json.zip")
+		Expect(ioutil.WriteFile(zipPath, []byte("old"), 0644)).Should(Succeed())
+		Expect(newExtractor().Extract()).Should(Succeed())
+		contents, err := ioutil.ReadFile(zipPath)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(contents)).ShouldNot(Equal("old"))
+	})
+
+	It("should return a clear error if the existing zip can't be replaced", func() {
+		// A non-empty directory can't be removed nor renamed over, even by root
+		zipPath := filepath.Join(outputDir, "repo_data_v2.json.zip")
+		Expect(os.MkdirAll(filepath.Join(zipPath, "locked"), 0755)).Should(Succeed())
+		err := newExtractor().Extract()
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("cannot replace old output file " + zipPath))
+		_, err = os.Stat(filepath.Join(outputDir, "repo_data_v2.json.tmp.zip"))
+		Expect(os.IsNotExist(err)).Should(BeTrue())
+	})
+
+	It("should leave no partial output if the archiving fails", func() {
+		defer extractor.SetArchive(func(sources []string, destination string) error {
+			Expect(ioutil.WriteFile(destination, []byte("partial"), 0644)).Should(Succeed())
+			return errors.New("disk full")
+		})()
+		Expect(newExtractor().Extract()).Should(MatchError("step export: disk full"))
+		files, err := ioutil.ReadDir(outputDir)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+
+		// An old zip is kept as it was
+		zipPath := filepath.Join(outputDir, "repo_data_v2.json.zip")
+		Expect(ioutil.WriteFile(zipPath, []byte("old"), 0644)).Should(Succeed())
+		Expect(newExtractor().Extract()).Should(MatchError("step export: disk full"))
+		files, err = ioutil.ReadDir(outputDir)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		contents, err := ioutil.ReadFile(zipPath)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("old"))
+	})
+
+	It("should write the versions into the metadata", func() {
+		metadata, _ := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.Version = "v1.2.3"
+		})
+		Expect(metadata["schemaVersion"]).To(BeEquivalentTo(extractor.SchemaVersion))
+		Expect(metadata["extractorVersion"]).To(Equal("v1.2.3"))
+	})
+
+	It("should write a gzip file", func() {
+		re := newExtractor()
+		re.OutputFormat = extractor.FormatGzip
+		Expect(re.Extract()).Should(Succeed())
+		file, err := os.Open(filepath.Join(outputDir, "repo_data_v2.jsonl.gz"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer file.Close()
+		reader, err := gzip.NewReader(file)
+		Expect(err).ShouldNot(HaveOccurred())
+		metadata, commits := parseOutput(reader)
+		Expect(metadata).To(HaveKey("repo"))
+		Expect(commits).To(HaveLen(1))
+
+		files, err := ioutil.ReadDir(outputDir)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("should write a plain JSON lines file", func() {
+		re := newExtractor()
+		re.OutputFormat = extractor.FormatJSONL
+		Expect(ioutil.WriteFile(filepath.Join(outputDir, "repo_data_v2.jsonl"), []byte("old"), 0644)).Should(Succeed())
+		Expect(re.Extract()).Should(Succeed())
+		file, err := os.Open(filepath.Join(outputDir, "repo_data_v2.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer file.Close()
+		metadata, commits := parseOutput(file)
+		Expect(metadata).To(HaveKey("repo"))
+		Expect(commits).To(HaveLen(1))
+
+		files, err := ioutil.ReadDir(outputDir)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("should refuse the per language layout without zip", func() {
+		re := newExtractor()
+		re.OutputFormat = extractor.FormatGzip
+		re.OutputLayout = extractor.LayoutPerLanguage
+		Expect(re.Extract()).Should(MatchError("step export: the per-language output layout needs the zip output format"))
+	})
+
+	It("should remove the raw data by default", func() {
+		Expect(newExtractor().Extract()).Should(Succeed())
+		_, err := os.Stat(filepath.Join(outputDir, "repo_data_v2.json"))
+		Expect(os.IsNotExist(err)).Should(BeTrue())
+	})
+
+	It("should keep the raw data if KeepRawData is set", func() {
+		re := newExtractor()
+		re.KeepRawData = true
+		Expect(re.Extract()).Should(Succeed())
+		file, err := os.Open(filepath.Join(outputDir, "repo_data_v2.json"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer file.Close()
+		metadata, commits := parseOutput(file)
+		Expect(metadata).To(HaveKey("repo"))
+		Expect(commits).To(HaveLen(1))
+	})
+
+	It("should keep the per language files if KeepRawData is set", func() {
+		re := newExtractor()
+		re.KeepRawData = true
+		re.OutputLayout = extractor.LayoutPerLanguage
+		Expect(re.Extract()).Should(Succeed())
+		_, err := os.Stat(filepath.Join(outputDir, "repo_data_v2_languages", "repo.json"))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("Email selection", func() {
+	commits := []*commit.Commit{
+		{AuthorName: "Alim Giray", AuthorEmail: "alim@example.com"},
+		{AuthorName: "", AuthorEmail: "anonymous@example.com"},
+		{AuthorName: "a -> b -> c", AuthorEmail: "arrows@example.com"},
+		{AuthorName: "Alim Giray", AuthorEmail: "alim@example.com"},
+	}
+
+	It("should list every email once and handle empty names", func() {
+		Expect(extractor.GetAllEmails(commits)).To(Equal([]string{
+			"Alim Giray -> alim@example.com",
+			"(no name) -> anonymous@example.com",
+			"a -> b -> c -> arrows@example.com",
+		}))
+	})
+
+	It("should get the emails back even if the names contain the separator", func() {
+		emails, emailsMap := extractor.GetEmailsWithoutNames(extractor.GetAllEmails(commits))
+		Expect(emails).To(Equal([]string{
+			"alim@example.com",
+			"anonymous@example.com",
+			"arrows@example.com",
+		}))
+		Expect(emailsMap).To(HaveLen(3))
+		Expect(emailsMap).To(HaveKey("arrows@example.com"))
+	})
+})
+
+var _ = Describe("ParseGitLog", func() {
+	It("should use the new path of renamed files", func() {
+		log := strings.Join([]string{
+			"|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Rename|||SEP||||||END|||",
+			"1\t2\tmain.go",
+			"0\t0\told.go => new.go",
+			"3\t1\tsrc/{old => new}/file.go",
+			"0\t0\t{ => lib}/util.go",
+			"2\t0\tlib/{internal => }/helper.go",
+		}, "\n")
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(commits).To(HaveLen(1))
+		paths := []string{}
+		for _, f := range commits[0].ChangedFiles {
+			paths = append(paths, f.Path)
+		}
+		Expect(paths).To(Equal([]string{"main.go", "new.go", "src/new/file.go", "lib/util.go", "lib/helper.go"}))
+		Expect(commits[0].ChangedFiles[2].Insertions).To(Equal(3))
+		Expect(commits[0].ChangedFiles[2].Deletions).To(Equal(1))
+	})
+
+	It("should skip the malformed numstat lines", func() {
+		log := strings.Join([]string{
+			"|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Spaces|||SEP||||||END|||",
+			"1\t2",
+			"3",
+			"   \t",
+			"4\t5\t",
+			"1\t0\tmy file.go",
+			"2\t1\tdocs/{old name => new name}/read me.md",
+		}, "\n")
+		var commits []*commit.Commit
+		var err error
+		Expect(func() {
+			commits, err = extractor.ParseGitLog(strings.NewReader(log))
+		}).NotTo(Panic())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(commits).To(HaveLen(1))
+		paths := []string{}
+		for _, f := range commits[0].ChangedFiles {
+			paths = append(paths, f.Path)
+		}
+		Expect(paths).To(Equal([]string{"my file.go", "docs/new name/read me.md"}))
+		Expect(commits[0].ChangedFiles[1].Insertions).To(Equal(2))
+	})
+
+	It("should read the paths with spaces from git", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		first := repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		second := repo.commit(testCommit{Files: map[string]string{"src/my file.go": "package main\n"}})
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, second)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits[0].ChangedFiles).To(HaveLen(1))
+		Expect(result.Commits[0].ChangedFiles[0].Path).To(Equal("src/my file.go"))
+		Expect(result.Commits[0].ChangedFiles[0].Insertions).To(Equal(1))
+	})
+
+	It("should unquote the quoted paths", func() {
+		log := strings.Join([]string{
+			"|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Quotes|||SEP||||||END|||",
+			"3\t0\t" + `"caf\303\251.txt"`,
+			"1\t0\t" + `"tab\tname.go"`,
+			"1\t0\t" + `"my \"quoted\" file.go"`,
+			"0\t0\t" + `"old\tname.go" => "src/new\tname.go"`,
+			"1\t0\t" + `"unterminated.go`,
+			` create mode 100644 "caf\303\251.txt"`,
+			` rename "old\tname.go" => "src/new\tname.go" (100%)`,
+		}, "\n")
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		paths := []string{}
+		for _, f := range commits[0].ChangedFiles {
+			paths = append(paths, f.Path)
+		}
+		Expect(paths).To(Equal([]string{"café.txt", "tab\tname.go", `my "quoted" file.go`, "src/new\tname.go", `"unterminated.go`}))
+		Expect(commits[0].ChangedFiles[0].ChangeType).To(Equal(commit.ChangeAdded))
+		Expect(commits[0].ChangedFiles[3].ChangeType).To(Equal(commit.ChangeRenamed))
+	})
+
+	It("should read the paths with special characters from git", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		first := repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		second := repo.commit(testCommit{Files: map[string]string{
+			"café.txt":     "café\n",
+			"tab\tname.go": "package main\n",
+			"my file.go":   "package main\n",
+		}})
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, second)
+		Expect(err).ShouldNot(HaveOccurred())
+		changeTypes := map[string]string{}
+		for _, f := range result.Commits[0].ChangedFiles {
+			changeTypes[f.Path] = f.ChangeType
+		}
+		Expect(changeTypes).To(Equal(map[string]string{
+			"café.txt":     commit.ChangeAdded,
+			"tab\tname.go": commit.ChangeAdded,
+			"my file.go":   commit.ChangeAdded,
+		}))
+	})
+
+	It("should merge the lines of the same path", func() {
+		log := strings.Join([]string{
+			"|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Duplicates|||SEP||||||END|||",
+			"1\t2\tmain.go",
+			"0\t0\told.go => util.go",
+			"3\t4\tmain.go",
+			"1\t0\tREADME.md",
+			"2\t0\treadme.md",
+			"5\t1\tutil.go",
+			" rename old.go => util.go (90%)",
+			"|||BEGIN|||def|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 16:04:05 2020 +0100|||SEP|||Next|||SEP||||||END|||",
+			"1\t1\tmain.go",
+		}, "\n")
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(commits).To(HaveLen(2))
+		lines := map[string][2]int{}
+		for _, f := range commits[0].ChangedFiles {
+			lines[f.Path] = [2]int{f.Insertions, f.Deletions}
+		}
+		Expect(commits[0].ChangedFiles).To(HaveLen(4))
+		Expect(lines).To(Equal(map[string][2]int{
+			"main.go":   {4, 6},
+			"util.go":   {5, 1},
+			"README.md": {1, 0},
+			"readme.md": {2, 0},
+		}))
+		Expect(commits[0].ChangedFiles[1].ChangeType).To(Equal(commit.ChangeRenamed))
+		Expect(commits[1].ChangedFiles).To(HaveLen(1))
+		Expect(commits[1].ChangedFiles[0].Insertions).To(Equal(1))
+	})
+
+	It("should record the domains of the author emails", func() {
+		header := "|||BEGIN|||%s|||SEP|||Test User|||SEP|||%s|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Subject|||SEP||||||END|||"
+		log := strings.Join([]string{
+			fmt.Sprintf(header, "a", "test@Example.COM"),
+			fmt.Sprintf(header, "b", "first@second@corp.example.org"),
+			fmt.Sprintf(header, "c", "no-domain"),
+			fmt.Sprintf(header, "d", "trailing@"),
+			fmt.Sprintf(header, "e", ""),
+		}, "\n")
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		domains := map[string]string{}
+		for _, c := range commits {
+			domains[c.Hash] = c.AuthorDomain
+		}
+		Expect(domains).To(Equal(map[string]string{
+			"a": "example.com",
+			"b": "corp.example.org",
+			"c": "",
+			"d": "",
+			"e": "",
+		}))
+	})
+
+	It("should flag the binary files", func() {
+		log := strings.Join([]string{
+			"|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Add the logo|||SEP||||||END|||",
+			"-\t-\tlogo.png",
+			"0\t0\tempty.go",
+			"3\t1\tmain.go",
+		}, "\n")
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(commits[0].ChangedFiles).To(HaveLen(3))
+		binary := commits[0].ChangedFiles[0]
+		Expect(binary.Path).To(Equal("logo.png"))
+		Expect(binary.Insertions).To(Equal(0))
+		Expect(binary.Deletions).To(Equal(0))
+		Expect(binary.IsBinary).To(BeTrue())
+		Expect(commits[0].ChangedFiles[1].IsBinary).To(BeFalse())
+		Expect(commits[0].ChangedFiles[2].IsBinary).To(BeFalse())
+	})
+
+	It("should read multi line messages", func() {
+		log := strings.Join([]string{
+			"|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||First subject|||SEP|||First line",
+			"",
+			"|||BEGIN||| is not a new commit",
+			"Last line",
+			"|||END|||",
+			"",
+			"1\t2\tmain.go",
+			"",
+			"|||BEGIN|||def|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 16:04:05 2020 +0100|||SEP|||Second subject|||SEP||||||END|||",
+			"",
+			"3\t4\tmain.go",
+		}, "\n")
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(commits).To(HaveLen(2))
+		Expect(commits[0].Subject).To(Equal("First subject"))
+		Expect(commits[0].Body).To(Equal("First line\n\n|||BEGIN||| is not a new commit\nLast line"))
+		Expect(commits[0].ChangedFiles).To(HaveLen(1))
+		Expect(commits[1].Subject).To(Equal("Second subject"))
+		Expect(commits[1].Body).To(Equal(""))
+		Expect(commits[1].ChangedFiles[0].Insertions).To(Equal(3))
+	})
+})
+
+var _ = Describe("LogSource", func() {
+	It("should parse the commits from the captured log", func() {
+		outputDir, err := ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(outputDir)
+		logFile, err := os.Open("./fixtures/git.log")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer logFile.Close()
+
+		re := extractor.RepoExtractor{
+			RepoPath:      outputDir,
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			Headless:      true,
+			SkipLibraries: true,
+			UserEmails:    []string{"alim@example.com", "peter@example.com"},
+			LogSource:     logFile,
+		}
+		Expect(re.Extract()).Should(Succeed())
+		_, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(commits).To(HaveLen(2))
+		Expect(commits[0].Hash).To(Equal("5d1cbb6d1e1e3f1f0c0e3c5e2b1f0b9d8c7a6f51"))
+		Expect(commits[0].AuthorEmail).To(Equal("alim@example.com"))
+		Expect(commits[0].Date).To(Equal("2021-03-02 10:15:00 +0100"))
+		Expect(commits[0].Subject).To(Equal("Add the parser"))
+		Expect(commits[0].Body).To(Equal("The parser reads\nthe output of git log."))
+		Expect(commits[0].ChangedFiles).To(HaveLen(2))
+		Expect(commits[0].ChangedFiles[0].Path).To(Equal("parser/parser.go"))
+		Expect(commits[0].ChangedFiles[0].Insertions).To(Equal(120))
+		Expect(commits[0].ChangedFiles[0].Deletions).To(Equal(3))
+		Expect(commits[1].AuthorName).To(Equal("Peter"))
+		Expect(commits[1].ChangedFiles).To(HaveLen(2))
+		Expect(commits[1].ChangedFiles[1].Path).To(Equal("logo.png"))
+	})
+})
+
+var _ = Describe("OutputPath", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	It("should create the missing directories of the output", func() {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.OutputPath = filepath.Join(outputDir, "nested", "dir", "repo_data")
+		})
+		Expect(commits).To(HaveLen(1))
+		_, err := os.Stat(filepath.Join(outputDir, "nested", "dir", "repo_data_v2.json"))
+		Expect(os.IsNotExist(err)).Should(BeTrue())
+	})
+
+	It("should fail if the output directory can't be created", func() {
+		Expect(ioutil.WriteFile(filepath.Join(outputDir, "file"), []byte{}, 0644)).Should(Succeed())
+		re := extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			OutputPath:    filepath.Join(outputDir, "file", "repo_data"),
+			Headless:      true,
+			SkipLibraries: true,
+			UserEmails:    []string{"test@example.com"},
+		}
+		err := re.Extract()
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(HavePrefix("step export: cannot create output directory " + filepath.Join(outputDir, "file")))
+	})
+})
+
+var _ = Describe("Email prompt", func() {
+	It("should not prompt without a terminal", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		restore := extractor.SetIsTerminal(func() bool { return false })
+		defer restore()
+
+		re := extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			SkipLibraries: true,
+		}
+		err := re.Extract()
+		Expect(err).Should(MatchError(extractor.ErrNotInteractive))
+		Expect(err.Error()).Should(ContainSubstring("--emails"))
+	})
+})
+
+var _ = Describe("ProgressFunc", func() {
+	It("should report the number of processed commits", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		for i := 0; i < 3; i++ {
+			repo.commit(testCommit{Files: map[string]string{"main.go": strings.Repeat("line\n", i+1)}})
+		}
+
+		reported := []int{}
+		extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.ProgressFunc = func(processed int) {
+				reported = append(reported, processed)
+			}
+		})
+		Expect(reported).ShouldNot(BeEmpty())
+		Expect(reported[len(reported)-1]).To(Equal(3))
+	})
+})
+
+var _ = Describe("Worker errors", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n\nfunc main() {}\n"}})
+		// git log can't diff the last commit anymore
+		repo.removeObject("HEAD^{tree}")
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	newExtractor := func() *extractor.RepoExtractor {
+		return &extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			Headless:      true,
+			SkipLibraries: true,
+			UserEmails:    []string{"test@example.com"},
+		}
+	}
+
+	It("should return the error of the git log workers", func() {
+		err := newExtractor().Extract()
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("unable to read tree"))
+	})
+
+	It("should tell the failing step and git command", func() {
+		err := newExtractor().Extract()
+		Expect(err.Error()).Should(HavePrefix("step analyseCommits: git -c core.quotePath=false log "))
+		Expect(err.Error()).Should(MatchRegexp(`failed: exit status \d+: fatal: unable to read tree`))
+		var commandErr *extractor.CommandError
+		Expect(errors.As(err, &commandErr)).Should(BeTrue())
+		Expect(commandErr.Args[:4]).To(Equal([]string{"git", "-c", "core.quotePath=false", "log"}))
+		Expect(commandErr.Output).Should(HavePrefix("fatal: unable to read tree"))
+	})
+})
+
+var _ = Describe("Not a repo", func() {
+	It("should refuse a directory which is not a git repository", func() {
+		dir, err := ioutil.TempDir("", "repo_info_extractor_not_a_repo")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		re := extractor.RepoExtractor{RepoPath: dir, Headless: true, UserEmails: []string{"test@example.com"}}
+		err = re.Extract()
+		Expect(err).To(MatchError(HavePrefix("step verifyRepo: " + dir + " is not a git repository: ")))
+		var commandErr *extractor.CommandError
+		Expect(errors.As(err, &commandErr)).Should(BeTrue())
+		Expect(commandErr.Output).To(ContainSubstring("not a git repository"))
+		// Nothing is written
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+
+	It("should tell if git can't run", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		re := extractor.RepoExtractor{RepoPath: repo.Path, GitPath: filepath.Join(repo.Path, "missing-git"), Headless: true}
+		err := re.Extract()
+		Expect(err).To(MatchError(HavePrefix("step verifyRepo: cannot run git in " + repo.Path + ": ")))
+		Expect(errors.Is(err, os.ErrNotExist)).Should(BeTrue())
+	})
+})
+
+var _ = Describe("Pagination", func() {
+	It("should read every commit exactly once", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		hashes := map[string]bool{}
+		for i := 0; i < 25; i++ {
+			hashes[repo.commit(testCommit{})] = true
+		}
+		// Many small windows, so the workers return results concurrently
+		defer extractor.SetCommitsPerJob(2)()
+
+		_, commits := extractRepo(repo, skipLibraries)
+
+		extracted := map[string]bool{}
+		for _, c := range commits {
+			Expect(extracted).ShouldNot(HaveKey(c.Hash))
+			extracted[c.Hash] = true
+		}
+		Expect(extracted).To(Equal(hashes))
+	})
+
+	It("should read the most recent MaxCommits commits", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		hashes := []string{}
+		for i := 0; i < 25; i++ {
+			hashes = append(hashes, repo.commit(testCommit{}))
+		}
+		defer extractor.SetCommitsPerJob(2)()
+
+		extract := func(maxCommits, concurrency int) []string {
+			_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+				re.SkipLibraries = true
+				re.MaxCommits = maxCommits
+				re.Concurrency = concurrency
+			})
+			extracted := []string{}
+			for _, c := range commits {
+				extracted = append(extracted, c.Hash)
+			}
+			return extracted
+		}
+
+		for _, concurrency := range []int{1, 3, 8} {
+			Expect(extract(7, concurrency)).To(ConsistOf(hashes[18:]), "concurrency %d", concurrency)
+			Expect(extract(1, concurrency)).To(ConsistOf(hashes[24]), "concurrency %d", concurrency)
+		}
+		Expect(extract(100, 0)).To(ConsistOf(hashes))
+	})
+
+	It("should refuse MaxCommits with Incremental", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, MaxCommits: 10, Incremental: true}
+		Expect(re.Extract()).To(MatchError("MaxCommits can't be combined with Incremental"))
+	})
+})
+
+var _ = Describe("Concurrency", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should extract every commit with a single worker", func() {
+		hashes := map[string]bool{}
+		for i := 0; i < 7; i++ {
+			hashes[repo.commit(testCommit{Files: map[string]string{
+				fmt.Sprintf("file%d.py", i): fmt.Sprintf("import lib%d\n", i),
+			}})] = true
+		}
+		defer extractor.SetCommitsPerJob(2)()
+
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.Concurrency = 1
+		})
+
+		extracted := map[string]bool{}
+		for _, c := range commits {
+			extracted[c.Hash] = true
+			Expect(c.Libraries["Python"]).To(HaveLen(1))
+		}
+		Expect(extracted).To(Equal(hashes))
+	})
+
+	It("should fail for negative concurrency", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, Concurrency: -1}
+		Expect(re.Extract()).To(MatchError("concurrency must not be negative, got -1"))
+	})
+})
+
+var _ = Describe("ExtractContext", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		for i := 0; i < 10; i++ {
+			repo.commit(testCommit{Files: map[string]string{"main.go": fmt.Sprintf("package main\n\n// %d\n", i)}})
+		}
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	newExtractor := func() *extractor.RepoExtractor {
+		return &extractor.RepoExtractor{
+			RepoPath:   repo.Path,
+			OutputPath: filepath.Join(outputDir, "repo_data"),
+			Headless:   true,
+			UserEmails: []string{"test@example.com"},
+		}
+	}
+
+	It("should stop when the context is cancelled during the extraction", func() {
+		defer extractor.SetCommitsPerJob(1)()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		re := newExtractor()
+		re.ProgressFunc = func(processed int) {
+			cancel()
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- re.ExtractContext(ctx)
+		}()
+		Eventually(done, 5*time.Second).Should(Receive(MatchError(context.Canceled)))
+		_, err := os.Stat(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should return the error of an expired deadline", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+		defer cancel()
+		Expect(newExtractor().ExtractContext(ctx)).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should extract the repo until the context is done", func() {
+		Expect(newExtractor().ExtractContext(context.Background())).Should(Succeed())
+		_, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(commits).To(HaveLen(10))
+	})
+})
+
+var _ = Describe("Library detection", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extractWith := func(maxFileBytes int) []*commit.Commit {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.MaxFileBytes = maxFileBytes
+		})
+		return commits
+	}
+
+	extract := func() []*commit.Commit {
+		return extractWith(0)
+	}
+
+	It("should skip the libraries of the files larger than MaxFileBytes", func() {
+		large := "import numpy\n" + strings.Repeat("x = 1\n", extractor.DefaultMaxFileBytes/6+1)
+		repo.commit(testCommit{Files: map[string]string{
+			"large.py": large,
+			"small.py": "import requests\n",
+		}})
+		languages := func(commits []*commit.Commit) map[string]string {
+			languages := map[string]string{}
+			for _, file := range commits[0].ChangedFiles {
+				languages[file.Path] = file.Language
+			}
+			return languages
+		}
+
+		commits := extract()
+		Expect(languages(commits)).To(Equal(map[string]string{"large.py": "Python", "small.py": "Python"}))
+		Expect(commits[0].Libraries["Python"]).To(ConsistOf("requests"))
+
+		commits = extractWith(-1)
+		Expect(languages(commits)).To(Equal(map[string]string{"large.py": "Python", "small.py": "Python"}))
+		Expect(commits[0].Libraries["Python"]).To(ConsistOf("numpy", "requests"))
+
+		commits = extractWith(10)
+		Expect(languages(commits)).To(Equal(map[string]string{"large.py": "Python", "small.py": "Python"}))
+		Expect(commits[0].Libraries["Python"]).To(BeEmpty())
+	})
+
+	It("should read the files of the file analyzers whatever their size", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"package-lock.json": `{"lockfileVersion": 2, "packages": {"node_modules/lodash": {"version": "4.17.21"}}}` + "\n",
+			"index.js":          "const express = require('express')\n",
+		}})
+		commits := extractWith(10)
+		Expect(commits[0].Libraries["JavaScript"]).To(ConsistOf("lodash@4.17.21"))
+	})
+
+	It("should record the npm files of TypeScript projects for TypeScript", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"package-lock.json": `{"lockfileVersion": 2, "packages": {"node_modules/lodash": {"version": "4.17.21"}, "node_modules/typescript": {"version": "4.1.3"}}}` + "\n",
+			"web/package.json":  `{"dependencies": {"react": "^17.0.0"}}` + "\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["TypeScript"]).To(ConsistOf("lodash@4.17.21", "typescript@4.1.3"))
+		Expect(commits[0].Libraries["JavaScript"]).To(ConsistOf("react"))
+	})
+
+	It("should skip the deleted files and follow the renamed ones", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"old.py":  "import numpy\nimport pandas\n\nprint(numpy, pandas)\n",
+			"gone.py": "import flask\n",
+		}})
+		hash := repo.commit(testCommit{
+			Files:   map[string]string{"new.py": "import numpy\nimport pandas\n\nprint(numpy, pandas)\nprint(1)\n"},
+			Deleted: []string{"old.py", "gone.py"},
+		})
+		buffer := gbytes.NewBuffer()
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.Verbose = true
+			re.Logger = log.New(buffer, "", 0)
+		})
+		var second *commit.Commit
+		for _, c := range commits {
+			if c.Hash == hash {
+				second = c
+			}
+		}
+		files := map[string][2]string{}
+		for _, f := range second.ChangedFiles {
+			files[f.Path] = [2]string{f.ChangeType, f.Language}
+		}
+		Expect(files).To(Equal(map[string][2]string{
+			"new.py":  {commit.ChangeRenamed, "Python"},
+			"gone.py": {commit.ChangeDeleted, ""},
+		}))
+		Expect(second.Libraries["Python"]).To(ConsistOf("numpy", "pandas"))
+		Expect(string(buffer.Contents())).To(ContainSubstring("Skipping gone.py of " + hash + ", it was deleted\n"))
+	})
+
+	It("should detect the libraries of identical files in every commit", func() {
+		numpy := "import numpy\n"
+		flask := "import flask\n"
+		repo.commit(testCommit{Files: map[string]string{"app.py": numpy, "copy.py": numpy, "notes.txt": numpy}})
+		repo.commit(testCommit{Files: map[string]string{"app.py": flask}})
+		repo.commit(testCommit{Files: map[string]string{"app.py": numpy}})
+		libraries := map[string][]string{}
+		languages := map[string]string{}
+		for _, c := range extract() {
+			libraries[c.Subject] = c.Libraries["Python"]
+			for _, f := range c.ChangedFiles {
+				languages[c.Subject+" "+f.Path] = f.Language
+			}
+		}
+		Expect(libraries).To(Equal(map[string][]string{
+			"commit 1": {"numpy", "numpy"},
+			"commit 2": {"flask"},
+			"commit 3": {"numpy"},
+		}))
+		Expect(languages).To(Equal(map[string]string{
+			"commit 1 app.py":    "Python",
+			"commit 1 copy.py":   "Python",
+			"commit 1 notes.txt": "",
+			"commit 2 app.py":    "Python",
+			"commit 3 app.py":    "Python",
+		}))
+	})
+
+	It("should detect the dependencies of Package.swift", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"Package.swift": "import PackageDescription\n\nlet package = Package(\n" +
+				"    dependencies: [.package(url: \"https://github.com/apple/swift-nio.git\", from: \"2.0.0\")]\n)\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["Swift"]).To(ConsistOf("PackageDescription", "swift-nio"))
+	})
+
+	It("should detect the gems of Gemfile and the gemspec", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"Gemfile":         "source 'https://rubygems.org'\ngem 'rails'\n",
+			"example.gemspec": "Gem::Specification.new do |spec|\n  spec.add_dependency \"thor\"\nend\n",
+			"lib/example.rb":  "require 'json'\nrequire_relative 'example/version'\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["Ruby"]).To(ConsistOf("rails", "thor", "json"))
+	})
+
+	It("should detect the package roots of the Java and Kotlin imports", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"App.java": "import java.util.List;\nimport org.springframework.boot.SpringApplication;\nimport static org.junit.Assert.*;\n",
+			"Main.kt":  "import kotlin.math.max\nimport io.ktor.server.netty.Netty\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["Java"]).To(ConsistOf("org.springframework", "org.junit"))
+		Expect(commits[0].Libraries["Kotlin"]).To(ConsistOf("io.ktor"))
+	})
+
+	It("should detect the crates of the Rust files", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"src/main.rs": "extern crate serde_json;\nuse std::io;\nuse tokio::{runtime, sync::mpsc};\nuse crate::config;\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["Rust"]).To(ConsistOf("serde_json", "tokio"))
+	})
+
+	It("should detect the PHP namespaces and the packages of composer.json", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"composer.json":  "{\"require\": {\"php\": \"^8.0\", \"laravel/framework\": \"^9.0\"}}",
+			"app/Client.php": "<?php\nuse GuzzleHttp\\Client;\nuse App\\Models\\User;\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["PHP"]).To(ConsistOf("laravel/framework", "GuzzleHttp"))
+	})
+
+	It("should detect the declared dependencies of the manifests", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"go.mod":               "module example.com/app\n\nrequire (\n\tgithub.com/pkg/errors v0.9.1\n)\n",
+			"web/package.json":     "{\"dependencies\": {\"express\": \"^4.17.1\"}}",
+			"api/requirements.txt": "requests==2.25.1\n-r dev.txt\n",
+			"Cargo.toml":           "[dependencies]\nserde = \"1.0\"\n",
+			"pom.xml":              "<project><dependencies><dependency><groupId>junit</groupId><artifactId>junit</artifactId></dependency></dependencies></project>",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["Go"]).To(ConsistOf("github.com/pkg/errors"))
+		Expect(commits[0].Libraries["JavaScript"]).To(ConsistOf("express"))
+		Expect(commits[0].Libraries["Python"]).To(ConsistOf("requests"))
+		Expect(commits[0].Libraries["Rust"]).To(ConsistOf("serde"))
+		Expect(commits[0].Libraries["Java"]).To(ConsistOf("junit:junit"))
+	})
+
+	It("should detect the imports of the stylesheets", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"main.scss":  "@use \"sass:math\";\n.a {\n  @import 'partials/buttons';\n}\n",
+			"theme.less": "@import (reference) \"mixins.less\";\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries["SCSS"]).To(ConsistOf("sass:math", "partials/buttons"))
+		Expect(commits[0].Libraries["Less"]).To(ConsistOf("mixins.less"))
+	})
+
+	It("should detect the language of the files without an extension", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"bin/run":    "#!/usr/bin/env python\nimport requests\n",
+			"Dockerfile": "FROM golang\n",
+			"LICENSE":    "MIT\n",
+		}})
+		commits := extract()
+		languages := map[string]string{}
+		for _, file := range commits[0].ChangedFiles {
+			languages[file.Path] = file.Language
+		}
+		Expect(languages).To(Equal(map[string]string{"bin/run": "Python", "Dockerfile": "Dockerfile", "LICENSE": ""}))
+		Expect(commits[0].Libraries["Python"]).To(ConsistOf("requests"))
+	})
+
+	It("should not detect the language of binary files", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"tool.py": "\x00\x01import requests\n",
+			"main.py": "import requests\n",
+		}})
+		commits := extract()
+		for _, file := range commits[0].ChangedFiles {
+			if file.Path == "tool.py" {
+				Expect(file.IsBinary).To(BeTrue())
+				Expect(file.Language).To(BeEmpty())
+			} else {
+				Expect(file.Language).To(Equal("Python"))
+			}
+		}
+	})
+
+	It("should detect the language of the extensions in any case", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"SCRIPT.PY": "import requests\n",
+			"App.Java":  "import java.util.List;\n",
+			"Shape.M":   "#import <Foundation/Foundation.h>\n",
+		}})
+		commits := extract()
+		languages := map[string]string{}
+		for _, file := range commits[0].ChangedFiles {
+			languages[file.Path] = file.Language
+		}
+		Expect(languages).To(Equal(map[string]string{"SCRIPT.PY": "Python", "App.Java": "Java", "Shape.M": "Objective-C"}))
+		Expect(commits[0].Libraries["Python"]).To(ConsistOf("requests"))
+	})
+
+	It("should use the analyzer of the language of each file", func() {
+		repo.commit(testCommit{Files: map[string]string{
+			"main.go":   "package main\n\nimport \"github.com/onsi/ginkgo\"\n",
+			"script.py": "import requests\n",
+			"Main.hs":   "import Data.List\n",
+		}})
+		commits := extract()
+		Expect(commits[0].Libraries).To(HaveLen(2))
+		Expect(commits[0].Libraries["Go"]).To(ConsistOf("github.com/onsi/ginkgo"))
+		Expect(commits[0].Libraries["Python"]).To(ConsistOf("requests"))
+		// Haskell has no analyzer, yet its language is recorded
+		Expect(commits[0].Libraries).NotTo(HaveKey("Haskell"))
+		for _, file := range commits[0].ChangedFiles {
+			if file.Path == "Main.hs" {
+				Expect(file.Language).To(Equal("Haskell"))
+			}
+		}
+	})
+})
+
+var _ = Describe("MinChurn", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Message: "Add main", Files: map[string]string{
+			"main.go": strings.Repeat("// line\n", 20),
+		}})
+		repo.commit(testCommit{Message: "Fix typo", Files: map[string]string{
+			"README.md": "typo fixed\n",
+		}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(minChurn int) []*commit.Commit {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.MinChurn = minChurn
+		})
+		return commits
+	}
+
+	It("should drop the commits changing fewer lines", func() {
+		commits := extract(10)
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].Subject).To(Equal("Add main"))
+	})
+
+	It("should keep every commit by default", func() {
+		Expect(extract(0)).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Change types", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should count the added, deleted and modified files", func() {
+		first := repo.commit(testCommit{Files: map[string]string{
+			"main.go":   "package main\n",
+			"old.go":    "package old\n",
+			"moved.txt": strings.Repeat("moved\n", 10),
+		}})
+		repo.git("mv", "moved.txt", "renamed.txt")
+		second := repo.commit(testCommit{
+			Files: map[string]string{
+				"main.go": "package main\n\nfunc main() {}\n",
+				"a.go":    "package a\n",
+				"b/b.go":  "package b\n",
+			},
+			Deleted: []string{"old.go"},
+		})
+
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, second)
+		Expect(err).ShouldNot(HaveOccurred())
+		c := result.Commits[0]
+		Expect(c.FilesAdded).To(Equal(2))
+		Expect(c.FilesDeleted).To(Equal(1))
+		Expect(c.FilesModified).To(Equal(2))
+
+		changeTypes := map[string]string{}
+		for _, f := range c.ChangedFiles {
+			changeTypes[f.Path] = f.ChangeType
+		}
+		Expect(changeTypes).To(Equal(map[string]string{
+			"main.go":     commit.ChangeModified,
+			"a.go":        commit.ChangeAdded,
+			"b/b.go":      commit.ChangeAdded,
+			"old.go":      commit.ChangeDeleted,
+			"renamed.txt": commit.ChangeRenamed,
+		}))
+	})
+})
+
+var _ = Describe("Blob hashes", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should record the blob hashes of the changed files", func() {
+		repo.commit(testCommit{Files: map[string]string{"old.go": "package old\n"}})
+		hash := repo.commit(testCommit{
+			Files:   map[string]string{"src/main.go": "package main\n", "with space.txt": "text\n"},
+			Deleted: []string{"old.go"},
+		})
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.RecordBlobHashes = true
+		})
+
+		blobHashes := map[string]string{}
+		for _, c := range commits {
+			if c.Hash != hash {
+				continue
+			}
+			for _, f := range c.ChangedFiles {
+				blobHashes[f.Path] = f.BlobHash
+			}
+		}
+		Expect(blobHashes).To(Equal(map[string]string{
+			"src/main.go":    repo.git("rev-parse", hash+":src/main.go"),
+			"with space.txt": repo.git("rev-parse", hash+":with space.txt"),
+			"old.go":         "",
+		}))
+	})
+})
+
+var _ = Describe("Separate git directory", func() {
+	var repo *testRepo
+	var gitDir, outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n\nimport \"github.com/onsi/ginkgo\"\n"}})
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		gitDir = filepath.Join(outputDir, "repo.git")
+		Expect(os.Rename(filepath.Join(repo.Path, ".git"), gitDir)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	It("should pass the git directory and the work tree to git", func() {
+		metadata, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.RepoPath = ""
+			re.GitDir = gitDir
+			re.WorkTree = repo.Path
+		})
+		Expect(metadata["repo"]).To(Equal(filepath.Base(repo.Path)))
+		Expect(commits).To(HaveLen(2))
+		Expect(commits[1].Libraries["Go"]).To(ConsistOf("github.com/onsi/ginkgo"))
+	})
+})
+
+var _ = Describe("Linked worktree", func() {
+	var repo, worktree *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		worktree = &testRepo{Path: filepath.Join(outputDir, "feature")}
+		repo.git("worktree", "add", "--quiet", "-b", "feature", worktree.Path)
+		worktree.commit(testCommit{Files: map[string]string{"feature.go": "package main\n"}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	It("should name the repo after the main repo of the worktree", func() {
+		metadata, commits := extractRepo(worktree, nil)
+		Expect(metadata["repo"]).To(Equal(filepath.Base(repo.Path)))
+		Expect(commits).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Ref", func() {
+	var repo *testRepo
+	var outputDir string
+	var first, second, third, feature string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		first = repo.commit(testCommit{Files: map[string]string{"a.go": "package a\n"}})
+		repo.git("tag", "v1.0")
+		second = repo.commit(testCommit{Files: map[string]string{"b.go": "package b\n"}})
+		third = repo.commit(testCommit{Files: map[string]string{"c.go": "package c\n"}})
+		repo.git("tag", "v2.0")
+		repo.git("checkout", "--quiet", "-b", "feature", first)
+		feature = repo.commit(testCommit{Files: map[string]string{"feature.go": "package feature\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	extract := func(ref string) ([]string, error) {
+		re := extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			Headless:      true,
+			SkipLibraries: true,
+			UserEmails:    []string{"test@example.com"},
+			Ref:           ref,
+		}
+		err := re.Extract()
+		if err != nil {
+			return nil, err
+		}
+		_, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		hashes := []string{}
+		for _, c := range commits {
+			hashes = append(hashes, c.Hash)
+		}
+		return hashes, nil
+	}
+
+	It("should read every ref by default", func() {
+		Expect(extract("")).To(ConsistOf(first, second, third, feature))
+	})
+
+	It("should read the commits of a branch", func() {
+		Expect(extract("feature")).To(ConsistOf(first, feature))
+	})
+
+	It("should read the commits of a range", func() {
+		Expect(extract("v1.0..v2.0")).To(ConsistOf(second, third))
+	})
+
+	It("should refuse Ref with ReadReflog", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, Ref: "feature", ReadReflog: true}
+		Expect(re.Extract()).To(MatchError("Ref can't be combined with ReadReflog"))
+	})
+
+	It("should fail for a missing ref", func() {
+		_, err := extract("missing")
+		Expect(err).To(MatchError("step verifyRef: invalid ref missing: missing is not a valid commit"))
+		_, err = extract("v1.0..missing")
+		Expect(err).To(MatchError("step verifyRef: invalid ref v1.0..missing: missing is not a valid commit"))
+	})
+})
+
+var _ = Describe("Deterministic", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		for i := 0; i < 8; i++ {
+			repo.commit(testCommit{
+				// Two commits share every date
+				Date: fmt.Sprintf("2020-01-01T%02d:00:00+0000", i/2),
+				Files: map[string]string{
+					fmt.Sprintf("b%d.py", i): fmt.Sprintf("import zlib%d\nimport alib%d\n", i, i),
+					fmt.Sprintf("a%d.go", i): "package main\n",
+				},
+			})
+		}
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	extract := func(format string) []byte {
+		// Small windows, so the workers finish in any order
+		defer extractor.SetCommitsPerJob(1)()
+		re := extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			OutputFormat:  format,
+			Headless:      true,
+			UserEmails:    []string{"test@example.com"},
+			Deterministic: true,
+		}
+		Expect(re.Extract()).Should(Succeed())
+		files, err := filepath.Glob(filepath.Join(outputDir, "repo_data_v2.*"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		output, err := ioutil.ReadFile(files[0])
+		Expect(err).ShouldNot(HaveOccurred())
+		return output
+	}
+
+	It("should write the same zip twice", func() {
+		first := extract(extractor.FormatZip)
+		Expect(extract(extractor.FormatZip)).To(Equal(first))
+
+		_, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(commits).To(HaveLen(8))
+		for i := 1; i < len(commits); i++ {
+			Expect(commits[i-1].Date <= commits[i].Date).To(BeTrue())
+			if commits[i-1].Date == commits[i].Date {
+				Expect(commits[i-1].Hash < commits[i].Hash).To(BeTrue())
+			}
+		}
+		for _, c := range commits {
+			Expect(c.ChangedFiles).To(HaveLen(2))
+			Expect(c.ChangedFiles[0].Path < c.ChangedFiles[1].Path).To(BeTrue())
+			Expect(c.Libraries["Python"]).To(HaveLen(2))
+			Expect(c.Libraries["Python"][0] < c.Libraries["Python"][1]).To(BeTrue())
+		}
+	})
+
+	It("should write the same gzip twice", func() {
+		first := extract(extractor.FormatGzip)
+		Expect(extract(extractor.FormatGzip)).To(Equal(first))
+	})
+})
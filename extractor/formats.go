@@ -0,0 +1,91 @@
+package extractor
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Output formats
+const (
+	FormatZip   = "zip"   // <OutputPath>_v2.json.zip
+	FormatGzip  = "gzip"  // <OutputPath>_v2.jsonl.gz
+	FormatJSONL = "jsonl" // <OutputPath>_v2.jsonl
+)
+
+// outputFilePath returns the path of the output file in OutputFormat
+func (r *RepoExtractor) outputFilePath() string {
+	switch r.OutputFormat {
+	case FormatGzip:
+		return r.outputPath() + "_v2.jsonl.gz"
+	case FormatJSONL:
+		return r.outputPath() + "_v2.jsonl"
+	default:
+		return r.zipPath()
+	}
+}
+
+// exportGzip compresses the output into a gzip file
+// The uncompressed file is removed unless KeepRawData is set.
+func (r *RepoExtractor) exportGzip() error {
+	repoDataPath := r.repoDataPath()
+	err := r.writeRepoData(repoDataPath)
+	if err != nil {
+		return err
+	}
+	if !r.KeepRawData {
+		defer os.Remove(repoDataPath)
+	}
+
+	return r.replaceOutputFile(func(w io.Writer) error {
+		source, err := os.Open(repoDataPath)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+		gz := gzip.NewWriter(w)
+		_, err = io.Copy(gz, source)
+		if err != nil {
+			return err
+		}
+		return gz.Close()
+	})
+}
+
+// exportJSONL writes the output without compression
+func (r *RepoExtractor) exportJSONL() error {
+	return r.replaceOutputFile(r.writeRepoDataTo)
+}
+
+// replaceOutputFile writes the output file next to the old one and replaces it when it's done
+func (r *RepoExtractor) replaceOutputFile(write func(w io.Writer) error) error {
+	return replaceFile(r.outputFilePath(), write)
+}
+
+// replaceFile writes a temporary file next to path and renames it to path when write succeeds
+// The file at path is either the old or the complete new one, the temporary file is removed on errors.
+func replaceFile(path string, write func(w io.Writer) error) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Rename replaces the old file in one step, on Windows too
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot replace old output file %s: %w", path, err)
+	}
+	return nil
+}
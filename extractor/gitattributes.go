@@ -0,0 +1,122 @@
+package extractor
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// linguistAttributes are the .gitattributes attributes which keep a file out of the language stats
+var linguistAttributes = []string{"linguist-generated", "linguist-vendored"}
+
+// attributeRule is a line of .gitattributes setting or unsetting the linguist attributes
+type attributeRule struct {
+	pattern  *regexp.Regexp
+	excluded bool
+}
+
+// initAttributes reads the linguist attributes from the .gitattributes of the default branch
+// Repos without .gitattributes have no rules. Nested .gitattributes files are not read.
+func (r *RepoExtractor) initAttributes() {
+	r.attributeRules = nil
+	out, err := r.runGit("--no-pager", "show", r.headRef()+":.gitattributes")
+	if err != nil {
+		return
+	}
+	r.attributeRules = parseGitAttributes(string(out))
+}
+
+// isLinguistExcluded reports whether .gitattributes marks the file as generated or vendored
+// The last matching rule wins, like in git.
+func (r *RepoExtractor) isLinguistExcluded(filePath string) bool {
+	excluded := false
+	for _, rule := range r.attributeRules {
+		if rule.pattern.MatchString(filePath) {
+			excluded = rule.excluded
+		}
+	}
+	return excluded
+}
+
+// parseGitAttributes returns the rules of the lines which mention a linguist attribute
+func parseGitAttributes(contents string) []attributeRule {
+	rules := []attributeRule{}
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		found := false
+		excluded := false
+		for _, attribute := range fields[1:] {
+			name, value := attribute, "true"
+			if i := strings.Index(attribute, "="); i != -1 {
+				name, value = attribute[:i], attribute[i+1:]
+			} else if strings.HasPrefix(attribute, "-") || strings.HasPrefix(attribute, "!") {
+				name, value = attribute[1:], "false"
+			}
+			for _, linguistAttribute := range linguistAttributes {
+				if name == linguistAttribute {
+					found = true
+					excluded = excluded || value == "true"
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+		pattern, err := attributePattern(fields[0])
+		if err != nil {
+			continue
+		}
+		rules = append(rules, attributeRule{pattern: pattern, excluded: excluded})
+	}
+	return rules
+}
+
+// attributePattern converts a .gitattributes pattern into a regex matching repo relative paths
+// Patterns without a slash match the file name in any directory.
+func attributePattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("(?:^|/)")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				expr.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.Index(pattern[i:], "]")
+			if end == -1 {
+				expr.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
@@ -0,0 +1,61 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Linguist attributes", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	languages := func() map[string]string {
+		_, commits := extractRepo(repo, nil)
+		languages := map[string]string{}
+		for _, c := range commits {
+			for _, f := range c.ChangedFiles {
+				languages[f.Path] = f.Language
+			}
+		}
+		return languages
+	}
+
+	It("should leave the generated and vendored files out of the language stats", func() {
+		attributes, err := ioutil.ReadFile("./fixtures/gitattributes")
+		Expect(err).ShouldNot(HaveOccurred())
+		repo.commit(testCommit{Files: map[string]string{
+			".gitattributes":            string(attributes),
+			"main.go":                   "package main\n",
+			"proto/service.pb.go":       "package proto\n",
+			"api/v1/client.gen.go":      "package v1\n",
+			"api/client.go":             "package api\n",
+			"third_party/lib/lib.go":    "package lib\n",
+			"third_party/mine/mine.go":  "package mine\n",
+			"internal/third_party/x.go": "package x\n",
+		}})
+		Expect(languages()).To(Equal(map[string]string{
+			".gitattributes":            "",
+			"main.go":                   "Go",
+			"proto/service.pb.go":       "",
+			"api/v1/client.gen.go":      "",
+			"api/client.go":             "Go",
+			"third_party/lib/lib.go":    "",
+			"third_party/mine/mine.go":  "Go",
+			"internal/third_party/x.go": "Go",
+		}))
+	})
+
+	It("should detect every language without .gitattributes", func() {
+		repo.commit(testCommit{Files: map[string]string{"proto/service.pb.go": "package proto\n"}})
+		Expect(languages()).To(Equal(map[string]string{"proto/service.pb.go": "Go"}))
+	})
+})
@@ -0,0 +1,253 @@
+package extractor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// parseLogDate converts a date of git log into dateFormat, it returns "" for invalid dates
+func parseLogDate(date string, logger Logger) string {
+	t, err := time.Parse("Mon Jan 2 15:04:05 2006 -0700", date)
+	if err != nil {
+		logger.Printf("Cannot convert date. Expected date format: Mon Jan 2 15:04:05 2006 -0700. Got: %s", date)
+		return ""
+	}
+	return t.Format(dateFormat)
+}
+
+// logHeaderFormat is the markers of the commit headers of a log format
+type logHeaderFormat struct {
+	begin     string
+	separator string
+	end       string
+}
+
+// logHeaderFormats are the header formats understood by parseGitLog
+// The first one is logFormat. The second one is the format of older captured logs, whose messages
+// must not contain its markers.
+var logHeaderFormats = []logHeaderFormat{
+	{begin: "\x1e", separator: "\x00", end: "\x00\x1e"},
+	{begin: "|||BEGIN|||", separator: "|||SEP|||", end: "|||END|||"},
+}
+
+// headerFormat returns the format of the header starting on the line, false if the line doesn't start a header
+func headerFormat(line string) (logHeaderFormat, bool) {
+	for _, format := range logHeaderFormats {
+		if strings.HasPrefix(line, format.begin) {
+			return format, true
+		}
+	}
+	return logHeaderFormat{}, false
+}
+
+// parseGitLog parses the output of git log --numstat formatted with logFormat
+// The skipped lines are reported to logger.
+func parseGitLog(reader io.Reader, logger Logger) ([]*commit.Commit, error) {
+	var commits []*commit.Commit
+
+	scanner := bufio.NewScanner(reader)
+	// A line of a commit message can be longer than the 64KB a scanner reads by default
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	currentLine := 0
+	var currectCommit *commit.Commit
+	// Index of the changed files of the current commit by path, git may list a path twice
+	fileIndexes := map[string]int{}
+	for scanner.Scan() {
+		m := scanner.Text()
+		currentLine++
+		if m == "" {
+			continue
+		}
+		if format, ok := headerFormat(m); ok {
+			// we reached a new commit
+			// save the existing
+			if currectCommit != nil {
+				currectCommit.CountChangedFiles()
+				commits = append(commits, currectCommit)
+			}
+
+			// read the rest of the multi line body
+			for !strings.HasSuffix(m, format.end) && scanner.Scan() {
+				m += "\n" + scanner.Text()
+			}
+
+			// and add new one commit
+			m = strings.TrimPrefix(m, format.begin)
+			m = strings.TrimSuffix(m, format.end)
+			bits := strings.Split(m, format.separator)
+			// Logs without the body, e.g. in an older format, can't be read
+			if len(bits) < 6 {
+				return nil, fmt.Errorf("malformed commit header on line %d of git log, expected at least 6 fields, got %d", currentLine, len(bits))
+			}
+			changedFiles := []*commit.ChangedFile{}
+			fileIndexes = map[string]int{}
+			dateStr := parseLogDate(bits[3], logger)
+			// Logs without the committer date are still understood
+			committerDateStr := ""
+			if len(bits) > 6 {
+				committerDateStr = parseLogDate(bits[6], logger)
+			}
+			currectCommit = &commit.Commit{
+				Hash:          bits[0],
+				AuthorName:    bits[1],
+				AuthorEmail:   bits[2],
+				AuthorDomain:  emailDomain(bits[2]),
+				Date:          dateStr,
+				CommitterDate: committerDateStr,
+				Subject:       bits[4],
+				Body:          strings.TrimRight(bits[5], "\n"),
+				ChangedFiles:  changedFiles,
+				Reviewers:     parseIdentityTrailers(bits[5], reviewerTrailers),
+				CoAuthors:     parseIdentityTrailers(bits[5], coAuthorTrailers),
+			}
+			continue
+		}
+
+		// summary lines start with a space, e.g. " create mode 100644 path"
+		if strings.HasPrefix(m, " ") {
+			if currectCommit != nil {
+				setChangeType(currectCommit, m)
+			}
+			continue
+		}
+
+		// numstat lines are "insertions<TAB>deletions<TAB>path", the path can contain spaces
+		bits := strings.SplitN(m, "\t", 3)
+		if len(bits) < 3 || bits[2] == "" {
+			logger.Printf("Skipping unexpected line %d of git log: %q", currentLine, m)
+			continue
+		}
+		// A stray line before the first commit doesn't discard the commits after it
+		if currectCommit == nil {
+			logger.Printf("Skipping line %d of git log before the first commit: %q", currentLine, m)
+			continue
+		}
+
+		// numstat shows "-" instead of the lines of binary files
+		isBinary := bits[0] == "-" && bits[1] == "-"
+		insertionsString := bits[0]
+		if insertionsString == "-" {
+			insertionsString = "0"
+		}
+		insertions, err := strconv.Atoi(insertionsString)
+		if err != nil {
+			logger.Printf("Cannot convert the following into integer: %s", insertionsString)
+			return nil, err
+		}
+
+		deletionsString := bits[1]
+		if deletionsString == "-" {
+			deletionsString = "0"
+		}
+		deletions, err := strconv.Atoi(deletionsString)
+		if err != nil {
+			logger.Printf("Cannot convert the following into integer: %s", deletionsString)
+			return nil, err
+		}
+
+		path := unquotePath(renamedPath(bits[2]))
+		changedFile := &commit.ChangedFile{
+			Path:       path,
+			Insertions: insertions,
+			Deletions:  deletions,
+			IsBinary:   isBinary,
+			Category:   fileCategory(path),
+			ChangeType: commit.ChangeModified,
+		}
+
+		// Paths are compared as they are, files differing only by case stay separate
+		if i, ok := fileIndexes[path]; ok {
+			existing := currectCommit.ChangedFiles[i]
+			existing.Insertions += insertions
+			existing.Deletions += deletions
+			existing.IsBinary = existing.IsBinary || isBinary
+			continue
+		}
+		fileIndexes[path] = len(currectCommit.ChangedFiles)
+		currectCommit.ChangedFiles = append(currectCommit.ChangedFiles, changedFile)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// last commit will not get appended otherwise
+	// because scanner is not returning anything
+	if currectCommit != nil {
+		currectCommit.CountChangedFiles()
+		commits = append(commits, currectCommit)
+	}
+
+	return commits, nil
+}
+
+var (
+	createDeleteSummaryRegex = regexp.MustCompile(`^ (create|delete) mode \d+ (.+)$`)
+	renameCopySummaryRegex   = regexp.MustCompile(`^ (rename|copy) (.+) \(\d+%\)$`)
+)
+
+// setChangeType sets the change type of the file in a --summary line
+// Lines like " mode change 100644 => 100755 path" leave the file modified.
+func setChangeType(c *commit.Commit, line string) {
+	var changeType, path string
+	if bits := createDeleteSummaryRegex.FindStringSubmatch(line); bits != nil {
+		changeType, path = commit.ChangeAdded, unquotePath(bits[2])
+		if bits[1] == "delete" {
+			changeType = commit.ChangeDeleted
+		}
+	} else if bits := renameCopySummaryRegex.FindStringSubmatch(line); bits != nil {
+		changeType, path = commit.ChangeRenamed, unquotePath(renamedPath(bits[2]))
+		if bits[1] == "copy" {
+			changeType = commit.ChangeAdded
+		}
+	} else {
+		return
+	}
+	for i := len(c.ChangedFiles) - 1; i >= 0; i-- {
+		if c.ChangedFiles[i].Path == path {
+			c.ChangedFiles[i].ChangeType = changeType
+			return
+		}
+	}
+}
+
+// renamedPath returns the new path from git's rename notation
+// like "old.go => new.go" or "src/{old => new}/file.go".
+// Paths without a rename are returned as they are.
+func renamedPath(path string) string {
+	arrow := strings.Index(path, " => ")
+	if arrow == -1 {
+		return path
+	}
+	start := strings.LastIndex(path[:arrow], "{")
+	end := strings.Index(path[arrow:], "}")
+	if start == -1 || end == -1 {
+		return path[arrow+len(" => "):]
+	}
+	end += arrow
+	newPath := path[:start] + path[arrow+len(" => "):end] + path[end+1:]
+	// Renames from or to the root like "{ => src}/file.go" leave extra slashes
+	newPath = strings.Replace(newPath, "//", "/", 1)
+	return strings.TrimPrefix(newPath, "/")
+}
+
+// unquotePath returns the path git quoted for containing special characters
+// like "tab\tname.go" or "caf\303\251.go". Git uses C-style escapes, which
+// strconv.Unquote understands, octal escapes are kept as raw bytes.
+// Paths without quotes are returned as they are.
+func unquotePath(path string) string {
+	if len(path) < 2 || path[0] != '"' || path[len(path)-1] != '"' {
+		return path
+	}
+	unquoted, err := strconv.Unquote(path)
+	if err != nil {
+		return path
+	}
+	return unquoted
+}
@@ -0,0 +1,188 @@
+package extractor_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+// logHeader formats a commit header of git log like the extractor does
+func logHeader(fields ...string) string {
+	return "\x1e" + strings.Join(fields, "\x00") + "\x00\x1e"
+}
+
+// testHeader is a commit header with every field
+var testHeader = logHeader("abc", "Test User", "test@example.com", "Mon Jan 6 15:04:05 2020 +0100", "Subject", "", "Tue Jan 7 10:00:00 2020 +0000")
+
+// parsedFile is the part of a changed file set by the parser
+type parsedFile struct {
+	Path       string
+	Insertions int
+	Deletions  int
+	IsBinary   bool
+	ChangeType string
+}
+
+func modified(path string, insertions, deletions int) parsedFile {
+	return parsedFile{Path: path, Insertions: insertions, Deletions: deletions, ChangeType: commit.ChangeModified}
+}
+
+var _ = Describe("ParseGitLog table", func() {
+	table.DescribeTable("should parse the changed files of a commit",
+		func(lines []string, expected []parsedFile) {
+			log := strings.Join(append([]string{testHeader}, lines...), "\n")
+			commits, err := extractor.ParseGitLog(strings.NewReader(log))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(commits).To(HaveLen(1))
+			files := []parsedFile{}
+			for _, f := range commits[0].ChangedFiles {
+				files = append(files, parsedFile{f.Path, f.Insertions, f.Deletions, f.IsBinary, f.ChangeType})
+			}
+			Expect(files).To(Equal(expected))
+		},
+		table.Entry("no files", []string{}, []parsedFile{}),
+		table.Entry("a modified file", []string{"3\t1\tmain.go"}, []parsedFile{modified("main.go", 3, 1)}),
+		table.Entry("empty lines", []string{"", "3\t1\tmain.go", ""}, []parsedFile{modified("main.go", 3, 1)}),
+		table.Entry("a binary file", []string{"-\t-\tlogo.png"},
+			[]parsedFile{{Path: "logo.png", IsBinary: true, ChangeType: commit.ChangeModified}}),
+		table.Entry("a path with spaces", []string{"1\t0\tmy dir/my file.go"}, []parsedFile{modified("my dir/my file.go", 1, 0)}),
+		table.Entry("a renamed file", []string{"0\t0\told.go => new.go"}, []parsedFile{modified("new.go", 0, 0)}),
+		table.Entry("a renamed directory", []string{"3\t1\tsrc/{old => new}/file.go"}, []parsedFile{modified("src/new/file.go", 3, 1)}),
+		table.Entry("a file moved from the root", []string{"0\t0\t{ => lib}/util.go"}, []parsedFile{modified("lib/util.go", 0, 0)}),
+		table.Entry("a file moved to the root", []string{"2\t0\tlib/{internal => }/helper.go"}, []parsedFile{modified("lib/helper.go", 2, 0)}),
+		table.Entry("a quoted path", []string{"1\t0\t" + `"tab\tname.go"`}, []parsedFile{modified("tab\tname.go", 1, 0)}),
+		table.Entry("an octal escaped path", []string{"1\t0\t" + `"caf\303\251.go"`}, []parsedFile{modified("café.go", 1, 0)}),
+		table.Entry("a created file", []string{"5\t0\tnew.go", " create mode 100644 new.go"},
+			[]parsedFile{{Path: "new.go", Insertions: 5, ChangeType: commit.ChangeAdded}}),
+		table.Entry("a deleted file", []string{"0\t5\told.go", " delete mode 100644 old.go"},
+			[]parsedFile{{Path: "old.go", Deletions: 5, ChangeType: commit.ChangeDeleted}}),
+		table.Entry("a rename summary", []string{"1\t1\tsrc/{old => new}/file.go", " rename src/{old => new}/file.go (90%)"},
+			[]parsedFile{{Path: "src/new/file.go", Insertions: 1, Deletions: 1, ChangeType: commit.ChangeRenamed}}),
+		table.Entry("a copy summary", []string{"0\t0\ta.go => b.go", " copy a.go => b.go (100%)"},
+			[]parsedFile{{Path: "b.go", ChangeType: commit.ChangeAdded}}),
+		table.Entry("a mode change", []string{"0\t0\trun.sh", " mode change 100644 => 100755 run.sh"}, []parsedFile{modified("run.sh", 0, 0)}),
+		table.Entry("a summary of an unknown file", []string{"1\t0\tmain.go", " create mode 100644 other.go"}, []parsedFile{modified("main.go", 1, 0)}),
+		table.Entry("the same path twice", []string{"1\t2\tmain.go", "3\t4\tmain.go"}, []parsedFile{modified("main.go", 4, 6)}),
+		table.Entry("paths differing by case", []string{"1\t0\tREADME.md", "2\t0\treadme.md"},
+			[]parsedFile{modified("README.md", 1, 0), modified("readme.md", 2, 0)}),
+		table.Entry("malformed lines", []string{"1\t2", "3", "4\t5\t", "1\t0\tmain.go"}, []parsedFile{modified("main.go", 1, 0)}),
+	)
+
+	table.DescribeTable("should parse the commits",
+		func(log string, hashes []string) {
+			commits, err := extractor.ParseGitLog(strings.NewReader(log))
+			Expect(err).ShouldNot(HaveOccurred())
+			parsed := []string{}
+			for _, c := range commits {
+				parsed = append(parsed, c.Hash)
+			}
+			Expect(parsed).To(Equal(hashes))
+		},
+		table.Entry("an empty log", "", []string{}),
+		table.Entry("only stray lines", "3\t1\tmain.go\n create mode 100644 main.go\n", []string{}),
+		table.Entry("a stray line before the first commit", "3\t1\tmain.go\n"+testHeader+"\n1\t0\tutil.go", []string{"abc"}),
+		table.Entry("consecutive commits", testHeader+"\n"+strings.Replace(testHeader, "abc", "def", 1), []string{"abc", "def"}),
+	)
+
+	table.DescribeTable("should parse the header",
+		func(header string, expected commit.Commit) {
+			commits, err := extractor.ParseGitLog(strings.NewReader(header))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(commits).To(HaveLen(1))
+			Expect(commits[0].AuthorName).To(Equal(expected.AuthorName))
+			Expect(commits[0].AuthorEmail).To(Equal(expected.AuthorEmail))
+			Expect(commits[0].Date).To(Equal(expected.Date))
+			Expect(commits[0].CommitterDate).To(Equal(expected.CommitterDate))
+			Expect(commits[0].Subject).To(Equal(expected.Subject))
+		},
+		table.Entry("every field", testHeader, commit.Commit{
+			AuthorName:    "Test User",
+			AuthorEmail:   "test@example.com",
+			Date:          "2020-01-06 15:04:05 +0100",
+			CommitterDate: "2020-01-07 10:00:00 +0000",
+			Subject:       "Subject",
+		}),
+		table.Entry("an older log without the committer date", "|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Subject|||SEP||||||END|||", commit.Commit{
+			AuthorName:  "Test User",
+			AuthorEmail: "test@example.com",
+			Date:        "2020-01-06 15:04:05 +0100",
+			Subject:     "Subject",
+		}),
+		table.Entry("an older log with an invalid date", "|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||yesterday|||SEP|||Subject|||SEP||||||END|||", commit.Commit{
+			AuthorName:  "Test User",
+			AuthorEmail: "test@example.com",
+			Subject:     "Subject",
+		}),
+		table.Entry("the markers of the older format in the subject", logHeader("abc", "Test User", "test@example.com", "Mon Jan 6 15:04:05 2020 +0100", "Fix |||SEP||| and |||END|||", "", ""), commit.Commit{
+			AuthorName:  "Test User",
+			AuthorEmail: "test@example.com",
+			Date:        "2020-01-06 15:04:05 +0100",
+			Subject:     "Fix |||SEP||| and |||END|||",
+		}),
+		table.Entry("an older log with separators in the subject", "|||BEGIN|||abc|||SEP||||||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Fix a|b||c|||SEP||||||END|||", commit.Commit{
+			AuthorEmail: "test@example.com",
+			Date:        "2020-01-06 15:04:05 +0100",
+			Subject:     "Fix a|b||c",
+		}),
+	)
+
+	table.DescribeTable("should fail for malformed headers",
+		func(header string) {
+			_, err := extractor.ParseGitLog(strings.NewReader(header + "\n1\t0\tmain.go"))
+			Expect(err).To(MatchError(ContainSubstring("malformed commit header on line 1 of git log")))
+		},
+		table.Entry("a header without the message", "|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||END|||"),
+		table.Entry("a header without the body", "|||BEGIN|||abc|||SEP|||Test User|||SEP|||test@example.com|||SEP|||Mon Jan 6 15:04:05 2020 +0100|||SEP|||Subject|||END|||"),
+		table.Entry("a truncated header", "|||BEGIN|||abc|||SEP|||Test User"),
+	)
+
+	It("should read bodies containing the markers of the older format", func() {
+		body := "Split on |||SEP||| no more\n|||BEGIN||| is no commit\nThe line ends |||END|||\n"
+		log := logHeader("abc", "Test User", "test@example.com", "Mon Jan 6 15:04:05 2020 +0100", "Subject", body, "Tue Jan 7 10:00:00 2020 +0000") +
+			"\n1\t0\tmain.go"
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].Body).To(Equal(strings.TrimSuffix(body, "\n")))
+		Expect(commits[0].CommitterDate).To(Equal("2020-01-07 10:00:00 +0000"))
+		Expect(commits[0].ChangedFiles).To(HaveLen(1))
+	})
+
+	It("should read bodies with lines longer than 64KB", func() {
+		body := strings.Repeat("a", 100*1024)
+		log := logHeader("abc", "Test User", "test@example.com", "Mon Jan 6 15:04:05 2020 +0100", "Subject", body, "Tue Jan 7 10:00:00 2020 +0000") +
+			"\n1\t0\tmain.go"
+		commits, err := extractor.ParseGitLog(strings.NewReader(log))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].Body).To(Equal(body))
+		Expect(commits[0].ChangedFiles).To(HaveLen(1))
+	})
+
+	It("should read the messages of git containing the markers of the older format", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		first := repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		hash := repo.commit(testCommit{
+			Message: "Fix |||SEP||| parsing\n\nThe line ends |||END|||\n|||BEGIN||| is no commit\n",
+			Files:   map[string]string{"main.go": "package main\n"},
+		})
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, hash)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits).To(HaveLen(1))
+		Expect(result.Commits[0].Subject).To(Equal("Fix |||SEP||| parsing"))
+		Expect(result.Commits[0].Body).To(Equal("The line ends |||END|||\n|||BEGIN||| is no commit"))
+		Expect(result.Commits[0].ChangedFiles).To(HaveLen(1))
+	})
+
+	It("should fail for lines which are not numbers", func() {
+		_, err := extractor.ParseGitLog(strings.NewReader(testHeader + "\nx\t1\tmain.go"))
+		Expect(err).Should(HaveOccurred())
+	})
+})
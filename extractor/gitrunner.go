@@ -0,0 +1,148 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// gitRunner runs the git commands of the extractor
+// The default runs the git executable, tests can replace it with a fake returning canned output.
+type gitRunner interface {
+	// Run runs git in dir and returns its stdout, a failure is a *CommandError
+	Run(ctx context.Context, dir string, args ...string) ([]byte, error)
+	// Stream starts git in dir and returns its stdout while it is running
+	// Close stops reading and waits for git, a failure is a *CommandError.
+	Stream(ctx context.Context, dir string, args ...string) (io.ReadCloser, error)
+}
+
+// CommandError is returned when a git command fails, it keeps what git printed
+type CommandError struct {
+	Args   []string // The command line, starting with git
+	Output string   // The trimmed output of the command, stderr only for the commands whose stdout is parsed
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s failed: %s", strings.Join(e.Args, " "), e.Err)
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// newCommandError wraps the error of git run with args with its command line and output
+func newCommandError(args []string, err error, output []byte) error {
+	return &CommandError{Args: append([]string{"git"}, args...), Output: strings.TrimSpace(string(output)), Err: err}
+}
+
+// execRunner runs the git executable in path
+type execRunner struct {
+	path string
+}
+
+func (e execRunner) command(ctx context.Context, dir string, args []string) (*exec.Cmd, *bytes.Buffer) {
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, e.path, args...)
+	cmd.Dir = dir
+	cmd.Env = gitEnv()
+	cmd.Stderr = stderr
+	return cmd, stderr
+}
+
+// gitEnv returns the environment of git
+// The C locale keeps the messages of git in English whatever the locale of the user is,
+// so the errors are the same everywhere. LANGUAGE is ignored by gettext in the C locale.
+func gitEnv() []string {
+	return append(os.Environ(), "LC_ALL=C")
+}
+
+func (e execRunner) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd, stderr := e.command(ctx, dir, args)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, newCommandError(args, err, stderr.Bytes())
+	}
+	return out, nil
+}
+
+func (e execRunner) Stream(ctx context.Context, dir string, args ...string) (io.ReadCloser, error) {
+	cmd, stderr := e.command(ctx, dir, args)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, newCommandError(args, err, nil)
+	}
+	return &commandStream{ReadCloser: stdout, cmd: cmd, args: args, stderr: stderr}, nil
+}
+
+// commandStream is the stdout of a running git command
+type commandStream struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	args   []string
+	stderr *bytes.Buffer
+}
+
+// Close stops reading the output and waits for git
+// Git fails if it couldn't write all of its output.
+func (s *commandStream) Close() error {
+	s.ReadCloser.Close()
+	err := s.cmd.Wait()
+	if err != nil {
+		return newCommandError(s.args, err, s.stderr.Bytes())
+	}
+	return nil
+}
+
+// runner returns the git runner of the extractor, by default it runs GitPath
+func (r *RepoExtractor) runner() gitRunner {
+	if r.gitRunner != nil {
+		return r.gitRunner
+	}
+	return execRunner{path: r.GitPath}
+}
+
+// gitArgs returns the directory and the arguments to run git with args in the repo
+// If GitDir or WorkTree is set it is passed to git, otherwise git runs in RepoPath.
+func (r *RepoExtractor) gitArgs(args []string) (string, []string) {
+	gitArgs := []string{}
+	if r.GitDir != "" {
+		gitArgs = append(gitArgs, "--git-dir="+r.GitDir)
+	}
+	if r.WorkTree != "" {
+		gitArgs = append(gitArgs, "--work-tree="+r.WorkTree)
+	}
+	if len(gitArgs) == 0 {
+		return r.RepoPath, args
+	}
+	return "", append(gitArgs, args...)
+}
+
+// runGit runs git in the repo and returns its stdout
+func (r *RepoExtractor) runGit(args ...string) ([]byte, error) {
+	return r.runGitContext(context.Background(), args...)
+}
+
+// runGitContext runs git in the repo and returns its stdout, git is killed when ctx is done
+func (r *RepoExtractor) runGitContext(ctx context.Context, args ...string) ([]byte, error) {
+	dir, gitArgs := r.gitArgs(args)
+	return r.runner().Run(ctx, dir, gitArgs...)
+}
+
+// streamGitContext starts git in the repo and returns its stdout, git is killed when ctx is done
+func (r *RepoExtractor) streamGitContext(ctx context.Context, args ...string) (io.ReadCloser, error) {
+	dir, gitArgs := r.gitArgs(args)
+	return r.runner().Stream(ctx, dir, gitArgs...)
+}
@@ -0,0 +1,291 @@
+package extractor_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+// fakeGit answers the git commands with canned output, it is called concurrently by the workers
+type fakeGit func(args []string) (string, error)
+
+func (f fakeGit) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	out, err := f(args)
+	if err != nil {
+		return nil, &extractor.CommandError{Args: append([]string{"git"}, args...), Err: err}
+	}
+	return []byte(out), nil
+}
+
+func (f fakeGit) Stream(ctx context.Context, dir string, args ...string) (io.ReadCloser, error) {
+	out, err := f.Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(strings.NewReader(string(out))), nil
+}
+
+// slowGit is fakeGit sleeping until it is killed for the git logs matched by slow
+type slowGit struct {
+	fakeGit
+	slow func(args []string) bool
+}
+
+func (f slowGit) Stream(ctx context.Context, dir string, args ...string) (io.ReadCloser, error) {
+	if !f.slow(args) {
+		return f.fakeGit.Stream(ctx, dir, args...)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, &extractor.CommandError{Args: append([]string{"git"}, args...), Err: errors.New("signal: killed")}
+	case <-time.After(time.Minute):
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	}
+}
+
+// bufferLogger returns a logger writing to buffer, which is safe for the concurrent workers
+func bufferLogger(buffer *gbytes.Buffer) extractor.Logger {
+	return log.New(buffer, "", 0)
+}
+
+// subcommand returns the first argument of git which is not an option
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-c":
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return args[i]
+		}
+	}
+	return ""
+}
+
+// contains reports whether args contains arg
+func contains(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+var _ = Describe("Git runner", func() {
+	var outputDir string
+
+	BeforeEach(func() {
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outputDir)
+	})
+
+	log := strings.Join([]string{
+		logHeader("abc", "Test User", "test@example.com", "Mon Jan 6 15:04:05 2020 +0100", "Add main", "", "Mon Jan 6 15:04:05 2020 +0100"),
+		"3\t0\tmain.go",
+		logHeader("def", "Other User", "other@example.com", "Tue Jan 7 15:04:05 2020 +0100", "Add util", "", "Tue Jan 7 15:04:05 2020 +0100"),
+		"1\t0\tutil.go",
+	}, "\n")
+
+	It("should extract the repo from the output of the fake", func() {
+		re := extractor.RepoExtractor{
+			RepoPath:      "/does/not/exist",
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			Headless:      true,
+			SkipLibraries: true,
+			SkipMailmap:   true,
+			UserEmails:    []string{"test@example.com"},
+		}
+		re.SetGitRunner(fakeGit(func(args []string) (string, error) {
+			switch subcommand(args) {
+			case "remote":
+				return "origin\thttps://github.com/owner/repo.git (fetch)\norigin\thttps://github.com/owner/repo.git (push)\n", nil
+			case "rev-parse":
+				if contains(args, "--is-inside-work-tree") {
+					return "true\n", nil
+				}
+			case "log":
+				// Every worker asks for a page of commits, only the first one has any
+				if contains(args, "--skip=0") {
+					return log, nil
+				}
+				return "", nil
+			}
+			return "", errors.New("exit status 128")
+		}))
+		Expect(re.Extract()).Should(Succeed())
+
+		metadata, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(metadata["repo"]).To(Equal("owner/repo"))
+		Expect(metadata["provider"]).To(Equal(extractor.ProviderGitHub))
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].Hash).To(Equal("abc"))
+		Expect(commits[0].ChangedFiles[0].Path).To(Equal("main.go"))
+	})
+
+	It("should fail for a git log taking longer than CommandTimeout", func() {
+		defer extractor.SetCommitsPerJob(1)()
+		re := extractor.RepoExtractor{
+			RepoPath:       "/does/not/exist",
+			OutputPath:     filepath.Join(outputDir, "repo_data"),
+			Headless:       true,
+			SkipLibraries:  true,
+			SkipMailmap:    true,
+			Concurrency:    1,
+			CommandTimeout: 100 * time.Millisecond,
+			UserEmails:     []string{"test@example.com", "other@example.com"},
+		}
+		// Every job reads one commit, the second one never arrives
+		firstCommit := strings.Join(strings.Split(log, "\n")[:2], "\n")
+		re.SetGitRunner(slowGit{
+			fakeGit: fakeGit(func(args []string) (string, error) {
+				switch subcommand(args) {
+				case "rev-parse":
+					if contains(args, "--is-inside-work-tree") {
+						return "true\n", nil
+					}
+				case "log":
+					if contains(args, "--skip=0") {
+						return firstCommit, nil
+					}
+					return "", nil
+				}
+				return "", errors.New("exit status 128")
+			}),
+			slow: func(args []string) bool {
+				return contains(args, "--skip=1")
+			},
+		})
+		err := re.Extract()
+		Expect(err).To(MatchError(ContainSubstring("git log of the commits 1 to 2 took longer than 100ms")))
+		Expect(filepath.Join(outputDir, "repo_data_v2.json.zip")).ShouldNot(BeAnExistingFile())
+	})
+
+	It("should return the error of the fake", func() {
+		re := extractor.RepoExtractor{RepoPath: "/does/not/exist", Headless: true, UserEmails: []string{"test@example.com"}}
+		re.SetGitRunner(fakeGit(func(args []string) (string, error) {
+			if contains(args, "--is-inside-work-tree") {
+				return "true\n", nil
+			}
+			return "", errors.New("exit status 128")
+		}))
+		err := re.Extract()
+		var commandErr *extractor.CommandError
+		Expect(errors.As(err, &commandErr)).Should(BeTrue())
+		Expect(subcommand(commandErr.Args[1:])).To(Equal("log"))
+	})
+})
+
+var _ = Describe("Git locale", func() {
+	var repo *testRepo
+	var outputDir string
+	var restore []func()
+
+	setenv := func(key, value string) {
+		original, ok := os.LookupEnv(key)
+		Expect(os.Setenv(key, value)).Should(Succeed())
+		restore = append(restore, func() {
+			if ok {
+				os.Setenv(key, original)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"app.py": "import numpy\n", "old.py": "import flask\n"}})
+		repo.commit(testCommit{Deleted: []string{"old.py"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		restore = nil
+		setenv("LANG", "de_DE.UTF-8")
+		setenv("LC_ALL", "de_DE.UTF-8")
+		setenv("LANGUAGE", "de")
+	})
+
+	AfterEach(func() {
+		for _, f := range restore {
+			f()
+		}
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	It("should run git in the C locale", func() {
+		// The wrapper records the locale of every git command
+		wrapper := filepath.Join(outputDir, "git")
+		locales := filepath.Join(outputDir, "locales")
+		script := "#!/bin/sh\necho \"$LC_ALL\" >> " + locales + "\nexec git \"$@\"\n"
+		Expect(ioutil.WriteFile(wrapper, []byte(script), 0755)).Should(Succeed())
+
+		re := extractor.RepoExtractor{
+			RepoPath:   repo.Path,
+			GitPath:    wrapper,
+			OutputPath: filepath.Join(outputDir, "repo_data"),
+			Headless:   true,
+			UserEmails: []string{"test@example.com"},
+		}
+		Expect(re.Extract()).Should(Succeed())
+		_, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(commits).To(HaveLen(2))
+
+		content, err := ioutil.ReadFile(locales)
+		Expect(err).ShouldNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		Expect(lines).NotTo(BeEmpty())
+		for _, line := range lines {
+			Expect(line).To(Equal("C"))
+		}
+	})
+
+	It("should skip the files of a git cat-file taking longer than CommandTimeout", func() {
+		// The wrapper never answers the requests of git cat-file
+		wrapper := filepath.Join(outputDir, "git")
+		script := "#!/bin/sh\nif [ \"$1\" = cat-file ]; then exec sleep 60; fi\nexec git \"$@\"\n"
+		Expect(ioutil.WriteFile(wrapper, []byte(script), 0755)).Should(Succeed())
+
+		buffer := gbytes.NewBuffer()
+		re := extractor.RepoExtractor{
+			RepoPath:       repo.Path,
+			GitPath:        wrapper,
+			OutputPath:     filepath.Join(outputDir, "repo_data"),
+			Headless:       true,
+			CommandTimeout: 100 * time.Millisecond,
+			UserEmails:     []string{"test@example.com"},
+			Logger:         bufferLogger(buffer),
+		}
+		Expect(re.Extract()).Should(Succeed())
+		_, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(commits).To(HaveLen(2))
+		for _, c := range commits {
+			Expect(c.Libraries).To(BeEmpty())
+		}
+		Expect(string(buffer.Contents())).To(ContainSubstring("Skipping app.py of"))
+		Expect(string(buffer.Contents())).To(ContainSubstring("git cat-file took longer than 100ms"))
+	})
+
+	It("should return the errors of git in English", func() {
+		repo.removeObject("HEAD^{tree}")
+		re := extractor.RepoExtractor{RepoPath: repo.Path, Headless: true, UserEmails: []string{"test@example.com"}}
+		Expect(re.Extract()).To(MatchError(ContainSubstring("fatal: unable to read tree")))
+	})
+})
@@ -0,0 +1,98 @@
+package extractor
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+const (
+	// LayoutSingle writes the metadata and every commit into one file
+	LayoutSingle = "single"
+	// LayoutPerLanguage writes the metadata into repo.json and the changed files
+	// into one file per language, e.g. Go.json
+	LayoutPerLanguage = "per-language"
+)
+
+// languageRecord is a changed file with the data of its commit
+type languageRecord struct {
+	CommitHash  string `json:"commitHash"`
+	AuthorEmail string `json:"authorEmail"`
+	Date        string `json:"createdAt"`
+	*commit.ChangedFile
+}
+
+// Language names can't be used as file names as they are, e.g. C++ or C#
+var languageFileNameReplacer = strings.NewReplacer("+", "plus", "#", "sharp", " ", "_", "/", "_")
+
+// writePerLanguage writes the output files of LayoutPerLanguage into dir and returns their paths
+// Files without a detected language are left out.
+func (r *RepoExtractor) writePerLanguage(dir string) ([]string, error) {
+	metadataPath := filepath.Join(dir, "repo.json")
+	repoMetaData, err := json.Marshal(r.repo)
+	if err != nil {
+		return nil, err
+	}
+	err = writeLines(metadataPath, [][]byte{repoMetaData})
+	if err != nil {
+		return nil, err
+	}
+
+	lines := map[string][][]byte{}
+	for _, c := range r.userCommits {
+		for _, f := range c.ChangedFiles {
+			if f.Language == "" {
+				continue
+			}
+			record, err := json.Marshal(languageRecord{
+				CommitHash:  c.Hash,
+				AuthorEmail: c.AuthorEmail,
+				Date:        c.Date,
+				ChangedFile: f,
+			})
+			if err != nil {
+				r.logf("Couldn't write changed file to file. CommitHash: %s Error: %s", c.Hash, err.Error())
+				continue
+			}
+			lines[f.Language] = append(lines[f.Language], record)
+		}
+	}
+
+	languages := make([]string, 0, len(lines))
+	for language := range lines {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	paths := []string{metadataPath}
+	for _, language := range languages {
+		path := filepath.Join(dir, languageFileNameReplacer.Replace(language)+".json")
+		err = writeLines(path, lines[language])
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeLines writes every line into a new file in path
+func writeLines(path string, lines [][]byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	for _, line := range lines {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
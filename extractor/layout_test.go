@@ -0,0 +1,81 @@
+package extractor_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mholt/archiver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("LayoutPerLanguage", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{
+			"main.go":   "package main\n\nimport \"fmt\"\n",
+			"script.py": "import os\n",
+			"README.md": "readme\n",
+		}})
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n\nimport \"os\"\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	It("should write one file per language", func() {
+		re := extractor.RepoExtractor{
+			RepoPath:     repo.Path,
+			OutputPath:   filepath.Join(outputDir, "repo_data"),
+			Headless:     true,
+			UserEmails:   []string{"test@example.com"},
+			OutputLayout: extractor.LayoutPerLanguage,
+		}
+		Expect(re.Extract()).Should(Succeed())
+
+		unzipDir := filepath.Join(outputDir, "unzip")
+		Expect(archiver.Unarchive(filepath.Join(outputDir, "repo_data_v2.json.zip"), unzipDir)).Should(Succeed())
+		files, err := ioutil.ReadDir(unzipDir)
+		Expect(err).ShouldNot(HaveOccurred())
+		names := []string{}
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		Expect(names).To(Equal([]string{"Go.json", "Python.json", "repo.json"}))
+
+		readRecords := func(name string) []map[string]interface{} {
+			contents, err := ioutil.ReadFile(filepath.Join(unzipDir, name))
+			Expect(err).ShouldNot(HaveOccurred())
+			records := []map[string]interface{}{}
+			for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+				record := map[string]interface{}{}
+				Expect(json.Unmarshal([]byte(line), &record)).Should(Succeed())
+				records = append(records, record)
+			}
+			return records
+		}
+
+		goRecords := readRecords("Go.json")
+		Expect(goRecords).To(HaveLen(2))
+		for _, record := range goRecords {
+			Expect(record["fileName"]).To(Equal("main.go"))
+			Expect(record["language"]).To(Equal("Go"))
+			Expect(record["commitHash"]).ShouldNot(BeEmpty())
+		}
+		Expect(readRecords("Python.json")).To(HaveLen(1))
+		Expect(readRecords("repo.json")[0]["emails"]).To(Equal([]interface{}{"test@example.com"}))
+	})
+})
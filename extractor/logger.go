@@ -0,0 +1,33 @@
+package extractor
+
+import (
+	"log"
+	"os"
+)
+
+// Logger receives the status messages of the extraction, *log.Logger implements it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// defaultLogger writes to stderr, so stdout stays clean for ListEmailsOnly and pipelines
+var defaultLogger Logger = log.New(os.Stderr, "", 0)
+
+func (r *RepoExtractor) logger() Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return defaultLogger
+}
+
+// logf logs a status message
+func (r *RepoExtractor) logf(format string, v ...interface{}) {
+	r.logger().Printf(format, v...)
+}
+
+// debugf logs a message about single commits and files if Verbose is set
+func (r *RepoExtractor) debugf(format string, v ...interface{}) {
+	if r.Verbose {
+		r.logger().Printf(format, v...)
+	}
+}
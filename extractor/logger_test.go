@@ -0,0 +1,84 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Logger", func() {
+	var repo *testRepo
+	var outputDir, hash string
+	var buffer *gbytes.Buffer
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		hash = repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		// gbytes.Buffer is safe for the concurrent workers
+		buffer = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	extract := func(verbose bool) string {
+		extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.Logger = log.New(buffer, "", 0)
+			re.Verbose = verbose
+		})
+		return string(buffer.Contents())
+	}
+
+	It("should write the status messages to the logger", func() {
+		output := extract(false)
+		Expect(output).To(ContainSubstring("Initializing repository\n"))
+		Expect(output).To(ContainSubstring("Analysing commits\n"))
+		Expect(output).To(ContainSubstring("Analysing libraries\n"))
+		Expect(output).To(ContainSubstring("Creating output file\n"))
+		Expect(output).NotTo(ContainSubstring(hash))
+	})
+
+	It("should log every commit and file if Verbose is set", func() {
+		output := extract(true)
+		Expect(output).To(ContainSubstring("Read commit " + hash + " by test@example.com changing 1 files\n"))
+		Expect(output).To(ContainSubstring("Detecting the libraries of commit " + hash + "\n"))
+		Expect(output).To(ContainSubstring("Language of main.go is Go\n"))
+	})
+
+	It("should skip a stray numstat line before the first commit", func() {
+		gitLog, err := ioutil.ReadFile("./fixtures/git.log")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		re := extractor.RepoExtractor{
+			RepoPath:      outputDir,
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			Headless:      true,
+			SkipLibraries: true,
+			UserEmails:    []string{"alim@example.com", "peter@example.com"},
+			LogSource:     strings.NewReader("3\t1\tstray.go\n" + string(gitLog)),
+			Logger:        log.New(buffer, "", 0),
+		}
+		Expect(re.Extract()).Should(Succeed())
+		_, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		Expect(commits).To(HaveLen(2))
+		for _, c := range commits {
+			for _, f := range c.ChangedFiles {
+				Expect(f.Path).NotTo(Equal("stray.go"))
+			}
+		}
+		Expect(buffer).To(gbytes.Say(`Skipping line 1 of git log before the first commit: "3\\t1\\tstray.go"`))
+	})
+})
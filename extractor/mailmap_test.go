@@ -0,0 +1,53 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Mailmap", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		mailmap, err := ioutil.ReadFile("./fixtures/mailmap")
+		Expect(err).ShouldNot(HaveOccurred())
+		repo.commit(testCommit{Name: "Alice Smith", Email: "alice@example.com", Files: map[string]string{".mailmap": string(mailmap)}})
+		repo.commit(testCommit{Name: "alice", Email: "alice@old.example.com", Files: map[string]string{"a.go": "package a\n"}})
+		repo.commit(testCommit{Name: "Alice", Email: "alice@laptop.local", Files: map[string]string{"b.go": "package b\n"}})
+		repo.commit(testCommit{Name: "Bob", Email: "bob@example.com", Files: map[string]string{"c.go": "package c\n"}})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(skipMailmap bool) []*commit.Commit {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.SkipMailmap = skipMailmap
+			re.UserEmails = []string{"alice@old.example.com"}
+		})
+		return commits
+	}
+
+	It("should canonicalize the authors and match the given emails", func() {
+		commits := extract(false)
+		Expect(commits).To(HaveLen(3))
+		for _, c := range commits {
+			Expect(c.AuthorName).To(Equal("Alice Smith"))
+			Expect(c.AuthorEmail).To(Equal("alice@example.com"))
+		}
+	})
+
+	It("should keep the original authors with SkipMailmap", func() {
+		commits := extract(true)
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].AuthorName).To(Equal("alice"))
+	})
+})
@@ -0,0 +1,42 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// reachableCommits returns the hashes of the commits reachable from any ref
+func (r *RepoExtractor) reachableCommits() (map[string]bool, error) {
+	out, err := r.runGit("rev-list", "--all")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the reachable commits: %w", err)
+	}
+	hashes := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		hashes[scanner.Text()] = true
+	}
+	return hashes, scanner.Err()
+}
+
+// skipOrphanedCommits flags the commits which no ref reaches and returns the rest
+func (r *RepoExtractor) skipOrphanedCommits(commits []*commit.Commit) ([]*commit.Commit, error) {
+	reachable, err := r.reachableCommits()
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]*commit.Commit, 0, len(commits))
+	r.repo.OrphanedCommits = 0
+	for _, c := range commits {
+		if !reachable[c.Hash] {
+			c.Orphaned = true
+			r.repo.OrphanedCommits++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept, nil
+}
@@ -0,0 +1,56 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Orphaned commits", func() {
+	var repo *testRepo
+	var orphan string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+		orphan = repo.commit(testCommit{Message: "Before the force push", Files: map[string]string{"util.go": "package main\n"}})
+		// Rewriting the commit leaves the old one reachable only from the reflog
+		repo.git("-c", "user.name=Test User", "-c", "user.email=test@example.com",
+			"commit", "--quiet", "--amend", "-m", "After the force push")
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(skipOrphanedCommits bool) (map[string]interface{}, []*commit.Commit) {
+		return extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.ReadReflog = true
+			re.SkipOrphanedCommits = skipOrphanedCommits
+		})
+	}
+
+	hashes := func(commits []*commit.Commit) []string {
+		hashes := []string{}
+		for _, c := range commits {
+			hashes = append(hashes, c.Hash)
+		}
+		return hashes
+	}
+
+	It("should read the commits of the reflog", func() {
+		_, commits := extract(false)
+		Expect(commits).To(HaveLen(3))
+		Expect(hashes(commits)).To(ContainElement(orphan))
+	})
+
+	It("should leave out and count the orphaned commits", func() {
+		metadata, commits := extract(true)
+		Expect(commits).To(HaveLen(2))
+		Expect(hashes(commits)).NotTo(ContainElement(orphan))
+		Expect(metadata["orphanedCommits"]).To(BeEquivalentTo(1))
+	})
+})
@@ -0,0 +1,54 @@
+package extractor
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Hosting providers of the repo
+const (
+	ProviderGitHub    = "github"
+	ProviderGitLab    = "gitlab"
+	ProviderBitbucket = "bitbucket"
+	ProviderAzure     = "azure"
+	ProviderOther     = "other"
+)
+
+// detectProvider tells the hosting provider from the host of the remote URL
+// Enterprise and self-hosted instances are recognized if the host names the provider, like github.example.com.
+func detectProvider(remoteURL string) string {
+	if remoteURL == "" {
+		return ""
+	}
+	host := strings.ToLower(remoteHost(remoteURL))
+	switch {
+	case host == "dev.azure.com" || host == "ssh.dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com"):
+		return ProviderAzure
+	case strings.Contains(host, "github"):
+		return ProviderGitHub
+	case strings.Contains(host, "gitlab"):
+		return ProviderGitLab
+	case strings.Contains(host, "bitbucket"):
+		return ProviderBitbucket
+	}
+	return ProviderOther
+}
+
+// remoteHost returns the host of URLs like https://host/path, ssh://user@host:port/path and user@host:path
+func remoteHost(remoteURL string) string {
+	if strings.Contains(remoteURL, "://") {
+		parsed, err := url.Parse(remoteURL)
+		if err != nil {
+			return ""
+		}
+		return parsed.Hostname()
+	}
+	host := remoteURL
+	if i := strings.Index(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	return host
+}
@@ -0,0 +1,29 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("DetectProvider", func() {
+	table.DescribeTable("should classify the remote URL",
+		func(url, provider string) {
+			Expect(extractor.DetectProvider(url)).To(Equal(provider))
+		},
+		table.Entry("GitHub over https", "https://github.com/codersrank-org/repo_info_extractor.git", extractor.ProviderGitHub),
+		table.Entry("GitHub over ssh", "git@github.com:codersrank-org/repo_info_extractor.git", extractor.ProviderGitHub),
+		table.Entry("GitHub Enterprise", "https://github.example.com/team/repo.git", extractor.ProviderGitHub),
+		table.Entry("GitLab", "https://gitlab.com/group/subgroup/repo.git", extractor.ProviderGitLab),
+		table.Entry("self-hosted GitLab over ssh", "ssh://git@gitlab.example.com:2222/group/repo.git", extractor.ProviderGitLab),
+		table.Entry("Bitbucket", "git@bitbucket.org:team/repo.git", extractor.ProviderBitbucket),
+		table.Entry("Bitbucket Server", "https://user@bitbucket.example.com/scm/project/repo.git", extractor.ProviderBitbucket),
+		table.Entry("Azure DevOps", "https://org@dev.azure.com/org/project/_git/repo", extractor.ProviderAzure),
+		table.Entry("Azure DevOps over ssh", "git@ssh.dev.azure.com:v3/org/project/repo", extractor.ProviderAzure),
+		table.Entry("Visual Studio Team Services", "https://org.visualstudio.com/project/_git/repo", extractor.ProviderAzure),
+		table.Entry("other host", "https://git.example.com/repo.git", extractor.ProviderOther),
+		table.Entry("no remote", "", ""),
+	)
+})
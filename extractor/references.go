@@ -0,0 +1,63 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// DefaultReferencePatterns find references like "#123", "Fixes #45", "owner/repo#12" and "Closes GH-67"
+var DefaultReferencePatterns = []string{
+	`(?:^|[^\w&/])(#\d+)\b`,
+	`\b([\w.-]+/[\w.-]+#\d+)\b`,
+	`\b(GH-\d+)\b`,
+}
+
+// referenceRegexes compiles ReferencePatterns
+func (r *RepoExtractor) referenceRegexes() ([]*regexp.Regexp, error) {
+	patterns := r.ReferencePatterns
+	if patterns == nil {
+		patterns = DefaultReferencePatterns
+	}
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference pattern %s: %w", pattern, err)
+		}
+		regexes = append(regexes, regex)
+	}
+	return regexes, nil
+}
+
+// setReferences records the issue and pull request references from the commit messages
+func (r *RepoExtractor) setReferences(commits []*commit.Commit) error {
+	regexes, err := r.referenceRegexes()
+	if err != nil {
+		return err
+	}
+	for _, c := range commits {
+		c.References = findReferences(c.Subject+"\n"+c.Body, regexes)
+	}
+	return nil
+}
+
+// findReferences returns the first group of every match or the whole match if there is no group
+func findReferences(message string, regexes []*regexp.Regexp) []string {
+	references := []string{}
+	found := map[string]bool{} // To prevent duplicates
+	for _, regex := range regexes {
+		for _, match := range regex.FindAllStringSubmatch(message, -1) {
+			reference := match[0]
+			if len(match) > 1 {
+				reference = match[1]
+			}
+			if !found[reference] {
+				found[reference] = true
+				references = append(references, reference)
+			}
+		}
+	}
+	return references
+}
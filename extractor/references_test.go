@@ -0,0 +1,63 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+	"github.com/codersrank-org/repo_info_extractor/obfuscation"
+)
+
+var _ = Describe("References", func() {
+	var repo *testRepo
+	var first, second string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		first = repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		second = repo.commit(testCommit{
+			Message: "Fix the parser (#123)\n\nFixes #45, closes GH-67 and codersrank-org/libraries#8.\n" +
+				"Mentioning #45 again and an &#38; entity.\n",
+			Files: map[string]string{"main.go": "package main\n"},
+		})
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should find the references in the message", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, second)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits[0].References).To(Equal([]string{"#123", "#45", "codersrank-org/libraries#8", "GH-67"}))
+	})
+
+	It("should use the custom patterns", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, ReferencePatterns: []string{`GH-\d+`}}
+		result, err := re.ExtractRange(first, second)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits[0].References).To(Equal([]string{"GH-67"}))
+	})
+
+	It("should obfuscate the references with the message", func() {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.Obfuscate = true
+			re.ReferencePatterns = []string{`GH-\d+`}
+		})
+		Expect(commits).To(HaveLen(2))
+		references := []string{}
+		for _, c := range commits {
+			Expect(c.Subject).To(BeEmpty())
+			references = append(references, c.References...)
+		}
+		Expect(references).To(Equal([]string{obfuscation.ObfuscateText("GH-67")}))
+	})
+
+	It("should fail for invalid patterns", func() {
+		re := extractor.RepoExtractor{RepoPath: repo.Path, ReferencePatterns: []string{`(`}}
+		_, err := re.ExtractRange(first, second)
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,50 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// revisionArgs selects the commits read by git log
+func (r *RepoExtractor) revisionArgs() []string {
+	args := []string{}
+	if r.SinceDate != "" {
+		args = append(args, "--since="+r.SinceDate)
+	}
+	if r.cursor != "" {
+		return append(args, r.cursor+".."+r.newestCommit)
+	}
+	if r.Ref != "" {
+		return append(args, r.Ref)
+	}
+	// Every ref, so the local work of the user on any branch counts
+	if r.ReadReflog {
+		return append(args, "--all", "--reflog")
+	}
+	return append(args, "--all")
+}
+
+// verifyRef returns an error if Ref or an end of the Ref range doesn't point to a commit
+func (r *RepoExtractor) verifyRef() error {
+	if r.Ref == "" {
+		return nil
+	}
+	ends := []string{r.Ref}
+	for _, separator := range []string{"...", ".."} {
+		if strings.Contains(r.Ref, separator) {
+			ends = strings.SplitN(r.Ref, separator, 2)
+			break
+		}
+	}
+	for _, end := range ends {
+		// An empty end of a range is HEAD
+		if end == "" {
+			end = "HEAD"
+		}
+		err := r.verifyCommit(end)
+		if err != nil {
+			return fmt.Errorf("invalid ref %s: %w", r.Ref, err)
+		}
+	}
+	return nil
+}
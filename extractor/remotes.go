@@ -0,0 +1,72 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/ui"
+)
+
+// defaultRemoteName is used if RemoteName is empty and the repo has such a remote
+const defaultRemoteName = "origin"
+
+var selectRemote = ui.SelectRemote
+
+// remote is a remote of the repo with its fetch URL
+type remote struct {
+	Name string
+	URL  string
+}
+
+// getRemotes lists the remotes of the repo in the order of git remote -v
+func (r *RepoExtractor) getRemotes() ([]remote, error) {
+	out, err := r.runGit("remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+	remotes := []remote{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// Lines look like "origin<TAB>git@github.com:owner/repo.git (fetch)"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[2] != "(fetch)" {
+			continue
+		}
+		remotes = append(remotes, remote{Name: fields[0], URL: fields[1]})
+	}
+	return remotes, scanner.Err()
+}
+
+// primaryRemote chooses the remote defining the name of the repo
+// RemoteName wins, then origin. Otherwise the user is asked in interactive mode, or the first remote is used.
+func (r *RepoExtractor) primaryRemote(remotes []remote) (remote, bool) {
+	if len(remotes) == 0 {
+		return remote{}, false
+	}
+	name := r.RemoteName
+	if name == "" {
+		name = defaultRemoteName
+	}
+	for _, rem := range remotes {
+		if rem.Name == name {
+			return rem, true
+		}
+	}
+	if r.RemoteName != "" {
+		r.logf("There is no remote named %s.", r.RemoteName)
+	}
+	if len(remotes) > 1 && !r.Headless && isTerminal() {
+		names := make([]string, 0, len(remotes))
+		for _, rem := range remotes {
+			names = append(names, rem.Name)
+		}
+		selected := selectRemote(names)
+		for _, rem := range remotes {
+			if rem.Name == selected {
+				return rem, true
+			}
+		}
+	}
+	return remotes[0], true
+}
@@ -0,0 +1,172 @@
+package extractor_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mholt/archiver"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+// testRepo is a throwaway git repository used by the tests
+type testRepo struct {
+	Path    string
+	commits int
+}
+
+// testCommit describes a commit created by testRepo.commit
+// Empty fields fall back to sensible defaults
+type testCommit struct {
+	Name          string
+	Email         string
+	Date          string // RFC 2822 or ISO 8601, it is passed to git as it is
+	CommitterDate string // Defaults to the current time
+	Message       string
+	Files         map[string]string // Path -> contents
+	Deleted       []string
+}
+
+func newTestRepo() *testRepo {
+	dir, err := ioutil.TempDir("", "repo_info_extractor_test")
+	Expect(err).ShouldNot(HaveOccurred())
+	repo := &testRepo{Path: dir}
+	repo.git("init", "--quiet")
+	return repo
+}
+
+// git runs git in the repo and returns the trimmed output
+func (t *testRepo) git(args ...string) string {
+	return t.gitWithEnv(nil, args...)
+}
+
+// gitWithEnv runs git like git with the additional environment variables
+func (t *testRepo) gitWithEnv(env []string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = t.Path
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_COMMITTER_NAME=Committer",
+		"GIT_COMMITTER_EMAIL=committer@example.com",
+	)
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.CombinedOutput()
+	Expect(err).ShouldNot(HaveOccurred(), string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// removeObject deletes the loose object rev points to, so git fails to read it
+func (t *testRepo) removeObject(rev string) {
+	hash := t.git("rev-parse", rev)
+	Expect(os.Remove(filepath.Join(t.Path, ".git", "objects", hash[:2], hash[2:]))).Should(Succeed())
+}
+
+// commit creates a new commit and returns its hash
+func (t *testRepo) commit(c testCommit) string {
+	t.commits++
+	if c.Name == "" {
+		c.Name = "Test User"
+	}
+	if c.Email == "" {
+		c.Email = "test@example.com"
+	}
+	if c.Date == "" {
+		c.Date = fmt.Sprintf("2020-01-01T%02d:00:00+0000", t.commits%24)
+	}
+	if c.Message == "" {
+		c.Message = fmt.Sprintf("commit %d", t.commits)
+	}
+	for name, contents := range c.Files {
+		path := filepath.Join(t.Path, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).Should(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).Should(Succeed())
+	}
+	for _, name := range c.Deleted {
+		Expect(os.Remove(filepath.Join(t.Path, name))).Should(Succeed())
+	}
+	t.git("add", "--all")
+	env := []string{}
+	if c.CommitterDate != "" {
+		env = append(env, "GIT_COMMITTER_DATE="+c.CommitterDate)
+	}
+	t.gitWithEnv(env, "-c", "user.name="+c.Name, "-c", "user.email="+c.Email,
+		"commit", "--quiet", "--allow-empty",
+		"--author", fmt.Sprintf("%s <%s>", c.Name, c.Email),
+		"--date", c.Date,
+		"-m", c.Message,
+	)
+	return t.git("rev-parse", "HEAD")
+}
+
+func (t *testRepo) remove() {
+	os.RemoveAll(t.Path)
+}
+
+// extractRepo extracts the repo headless for test@example.com and returns the metadata and the commits of the output
+// opts, if not nil, changes the extractor before the extraction. The output is removed unless opts sets OutputPath.
+func extractRepo(repo *testRepo, opts func(re *extractor.RepoExtractor)) (map[string]interface{}, []*commit.Commit) {
+	outputDir, err := ioutil.TempDir("", "repo_info_extractor_output")
+	Expect(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(outputDir)
+	re := &extractor.RepoExtractor{
+		RepoPath:   repo.Path,
+		OutputPath: filepath.Join(outputDir, "repo_data"),
+		Headless:   true,
+		UserEmails: []string{"test@example.com"},
+	}
+	if opts != nil {
+		opts(re)
+	}
+	Expect(re.Extract()).Should(Succeed())
+	return readOutput(re.OutputPath + "_v2.json.zip")
+}
+
+// skipLibraries is an option of extractRepo for the tests which don't need the library detection
+func skipLibraries(re *extractor.RepoExtractor) {
+	re.SkipLibraries = true
+}
+
+// readOutput unzips the output of an extraction and returns the repo metadata and the commits
+func readOutput(zipPath string) (map[string]interface{}, []*commit.Commit) {
+	dir, err := ioutil.TempDir("", "repo_info_extractor_unzip")
+	Expect(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+	Expect(archiver.Unarchive(zipPath, dir)).Should(Succeed())
+
+	files, err := ioutil.ReadDir(dir)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(files).To(HaveLen(1))
+	file, err := os.Open(filepath.Join(dir, files[0].Name()))
+	Expect(err).ShouldNot(HaveOccurred())
+	defer file.Close()
+
+	return parseOutput(file)
+}
+
+// parseOutput parses the metadata line and the commit lines of the output
+func parseOutput(reader io.Reader) (map[string]interface{}, []*commit.Commit) {
+	var metadata map[string]interface{}
+	commits := []*commit.Commit{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if metadata == nil {
+			Expect(json.Unmarshal(scanner.Bytes(), &metadata)).Should(Succeed())
+			continue
+		}
+		c := &commit.Commit{}
+		Expect(json.Unmarshal(scanner.Bytes(), c)).Should(Succeed())
+		commits = append(commits, c)
+	}
+	Expect(scanner.Err()).ShouldNot(HaveOccurred())
+	return metadata, commits
+}
@@ -0,0 +1,36 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// Result is the in-memory output of an extraction, the same data as the output file
+type Result struct {
+	Metadata *RepoMetadata    // Nil for ExtractRange, which computes no repo statistics
+	Commits  []*commit.Commit // Of the user, every commit of the range for ExtractRange
+}
+
+// ErrNotExtracted is returned by Results if no extraction has finished yet
+var ErrNotExtracted = errors.New("the repo hasn't been extracted yet")
+
+// Results returns the output of the last extraction
+// The data isn't copied, the output files are written from it.
+func (r *RepoExtractor) Results() (*Result, error) {
+	if !r.extracted {
+		return nil, ErrNotExtracted
+	}
+	return &Result{Metadata: r.repo, Commits: r.userCommits}, nil
+}
+
+// ExtractResults extracts a single repo like ExtractContext, but returns the output instead of writing and uploading it
+// Only the cursor of an incremental extraction is saved.
+func (r *RepoExtractor) ExtractResults(ctx context.Context) (*Result, error) {
+	err := r.extract(ctx, func() error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	return r.Results()
+}
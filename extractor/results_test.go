@@ -0,0 +1,85 @@
+package extractor_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Results", func() {
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n\nimport \"fmt\"\n"}})
+		repo.commit(testCommit{Files: map[string]string{"app.py": "import os\n"}})
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	newExtractor := func() *extractor.RepoExtractor {
+		return &extractor.RepoExtractor{
+			RepoPath:   repo.Path,
+			OutputPath: filepath.Join(outputDir, "repo_data"),
+			Headless:   true,
+			UserEmails: []string{"test@example.com"},
+		}
+	}
+
+	It("should match the output file", func() {
+		var repoExtractor *extractor.RepoExtractor
+		metadata, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			repoExtractor = re
+		})
+
+		data, err := repoExtractor.Results()
+		Expect(err).ShouldNot(HaveOccurred())
+		encoded, err := json.Marshal(data.Metadata)
+		Expect(err).ShouldNot(HaveOccurred())
+		var resultMetadata map[string]interface{}
+		Expect(json.Unmarshal(encoded, &resultMetadata)).Should(Succeed())
+		Expect(resultMetadata).To(Equal(metadata))
+
+		resultCommits := []*commit.Commit{}
+		for _, c := range data.Commits {
+			encoded, err := json.Marshal(c)
+			Expect(err).ShouldNot(HaveOccurred())
+			decoded := &commit.Commit{}
+			Expect(json.Unmarshal(encoded, decoded)).Should(Succeed())
+			resultCommits = append(resultCommits, decoded)
+		}
+		Expect(resultCommits).To(Equal(commits))
+		Expect(resultCommits).To(HaveLen(2))
+	})
+
+	It("should fail before the extraction", func() {
+		_, err := newExtractor().Results()
+		Expect(err).Should(MatchError(extractor.ErrNotExtracted))
+	})
+
+	It("should not write any file with ExtractResults", func() {
+		data, err := newExtractor().ExtractResults(context.Background())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(data.Commits).To(HaveLen(2))
+		Expect(data.Metadata.Emails).To(Equal([]string{"test@example.com"}))
+		Expect(data.Metadata.LanguageSummary).To(HaveKey("Go"))
+		files, err := ioutil.ReadDir(outputDir)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+})
@@ -0,0 +1,190 @@
+package extractor
+
+// OutputSchema returns the JSON Schema of the output of SchemaVersion
+// The output has a line per JSON document: the first one is the repo, every further line is a commit.
+// Validate them against the repo and commit definitions of the schema.
+func OutputSchema() []byte {
+	return []byte(outputSchema)
+}
+
+// outputSchema has to change together with the repo, commit.Commit and commit.ChangedFile structs
+const outputSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://codersrank.io/schemas/repo_info_extractor/v2.json",
+  "title": "repo_info_extractor output, schema version 2",
+  "description": "The first line of the output is a repo, every further line is a commit",
+  "definitions": {
+    "repo": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["schemaVersion", "extractorVersion", "repo", "primaryRemoteUrl", "provider", "defaultBranch", "emails", "suggestedEmails", "topDirectories", "averageCommitGap", "medianCommitGap", "weeklyActivity", "timeZones", "timeZoneSource", "languageAddDelete", "languageSummary", "languagesIntroduced", "primaryLanguages", "authorStats", "orphanedCommits", "maxLanguagesInCommit", "weightedScore"],
+      "properties": {
+        "schemaVersion": {"type": "integer", "enum": [2]},
+        "extractorVersion": {"type": "string"},
+        "repo": {"type": "string"},
+        "primaryRemoteUrl": {"type": "string", "description": "Empty if the repo has no remote"},
+        "provider": {"type": "string", "enum": ["", "github", "gitlab", "bitbucket", "azure", "other"]},
+        "defaultBranch": {"type": "string", "description": "Empty if it is unknown"},
+        "emails": {"type": ["array", "null"], "items": {"type": "string"}},
+        "suggestedEmails": {"type": ["array", "null"], "items": {"type": "string"}},
+        "topDirectories": {"type": ["array", "null"], "items": {"$ref": "#/definitions/dirCount"}},
+        "firstCommitAt": {"type": "string", "description": "Date of the user's earliest commit, omitted without commits"},
+        "lastCommitAt": {"type": "string", "description": "Date of the user's latest commit, omitted without commits"},
+        "averageCommitGap": {"type": "integer", "description": "In seconds"},
+        "medianCommitGap": {"type": "integer", "description": "In seconds"},
+        "weeklyActivity": {"type": ["array", "null"], "items": {"$ref": "#/definitions/weekStat"}},
+        "timeZones": {"type": ["array", "null"], "items": {"$ref": "#/definitions/timeZoneCount"}},
+        "timeZoneSource": {"type": "string", "enum": ["author", "committer"]},
+        "languageAddDelete": {
+          "type": ["object", "null"],
+          "description": "Lines inserted and deleted by the user by language",
+          "additionalProperties": {"$ref": "#/definitions/addDelete"}
+        },
+        "languageSummary": {
+          "type": ["object", "null"],
+          "description": "Commits and lines of the user by language, the files without a language are under Unknown",
+          "additionalProperties": {"$ref": "#/definitions/languageStat"}
+        },
+        "dominatedFiles": {"type": "array", "items": {"type": "string"}},
+        "languagesIntroduced": {"type": "array", "items": {"type": "string"}},
+        "primaryLanguages": {"type": "array", "maxItems": 3, "items": {"type": "string"}, "description": "Languages where the user changed the most lines, the first one the most"},
+        "authorStats": {
+          "type": ["object", "null"],
+          "description": "Commits and lines of the user by author email",
+          "additionalProperties": {"$ref": "#/definitions/authorStats"}
+        },
+        "orphanedCommits": {"type": "integer"},
+        "maxLanguagesInCommit": {"type": "integer"},
+        "weightedScore": {"type": "number", "description": "Sum of the weighted scores of the commits"},
+        "leaderboard": {"type": "array", "items": {"$ref": "#/definitions/contributorStat"}}
+      }
+    },
+    "dirCount": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["path", "count"],
+      "properties": {
+        "path": {"type": "string"},
+        "count": {"type": "integer"}
+      }
+    },
+    "weekStat": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["week", "weekStart", "commits", "netLines"],
+      "properties": {
+        "week": {"type": "string", "description": "ISO week, e.g. 2021-W01"},
+        "weekStart": {"type": "string", "description": "Date of the Monday starting the week"},
+        "commits": {"type": "integer"},
+        "netLines": {"type": "integer"}
+      }
+    },
+    "timeZoneCount": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["offset", "commits"],
+      "properties": {
+        "offset": {"type": "string", "description": "UTC offset, e.g. +0100"},
+        "commits": {"type": "integer"}
+      }
+    },
+    "languageStat": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["commits", "insertions", "deletions"],
+      "properties": {
+        "commits": {"type": "integer"},
+        "insertions": {"type": "integer"},
+        "deletions": {"type": "integer"}
+      }
+    },
+    "authorStats": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["commits", "insertions", "deletions", "files"],
+      "properties": {
+        "commits": {"type": "integer"},
+        "insertions": {"type": "integer"},
+        "deletions": {"type": "integer"},
+        "files": {"type": "integer", "description": "Distinct paths changed by the commits"}
+      }
+    },
+    "addDelete": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["insertions", "deletions"],
+      "properties": {
+        "insertions": {"type": "integer"},
+        "deletions": {"type": "integer"}
+      }
+    },
+    "contributorStat": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["name", "email", "commits", "netLines", "languages"],
+      "properties": {
+        "name": {"type": "string"},
+        "email": {"type": "string"},
+        "commits": {"type": "integer"},
+        "netLines": {"type": "integer"},
+        "languages": {"type": ["array", "null"], "items": {"type": "string"}}
+      }
+    },
+    "commit": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["commitHash", "authorName", "authorEmail", "authorDomain", "createdAt", "committedAt", "subject", "body", "changedFiles", "libraries", "reviewers", "coAuthors", "references", "languageCount", "filesAdded", "filesDeleted", "filesModified", "weightedScore"],
+      "properties": {
+        "commitHash": {"type": "string"},
+        "authorName": {"type": "string"},
+        "authorEmail": {"type": "string"},
+        "authorDomain": {"type": "string", "description": "Empty if the email has no domain"},
+        "createdAt": {"type": "string", "description": "Author date"},
+        "committedAt": {"type": "string", "description": "Committer date, empty if the log didn't have it"},
+        "subject": {"type": "string"},
+        "body": {"type": "string"},
+        "changedFiles": {"type": ["array", "null"], "items": {"$ref": "#/definitions/changedFile"}},
+        "libraries": {
+          "type": ["object", "null"],
+          "description": "Libraries by language",
+          "additionalProperties": {"type": ["array", "null"], "items": {"type": "string"}}
+        },
+        "reviewers": {"type": ["array", "null"], "items": {"$ref": "#/definitions/author"}},
+        "coAuthors": {"type": ["array", "null"], "items": {"$ref": "#/definitions/author"}, "description": "From the Co-authored-by trailers"},
+        "references": {"type": ["array", "null"], "items": {"type": "string"}},
+        "languageCount": {"type": "integer"},
+        "filesAdded": {"type": "integer"},
+        "filesDeleted": {"type": "integer"},
+        "filesModified": {"type": "integer"},
+        "weightedScore": {"type": "number", "description": "Changed lines weighted by the paths of the files"},
+        "orphaned": {"type": "boolean"},
+        "isLikelySquash": {"type": "boolean"}
+      }
+    },
+    "author": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["name", "email"],
+      "properties": {
+        "name": {"type": "string"},
+        "email": {"type": "string"}
+      }
+    },
+    "changedFile": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["fileName", "insertions", "deletions", "language", "changeType"],
+      "properties": {
+        "fileName": {"type": "string"},
+        "insertions": {"type": "integer"},
+        "deletions": {"type": "integer"},
+        "isBinary": {"type": "boolean"},
+        "language": {"type": "string"},
+        "category": {"type": "string"},
+        "changeType": {"type": "string", "enum": ["added", "deleted", "modified", "renamed"]},
+        "blobHash": {"type": "string", "description": "Empty for deleted files"}
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,188 @@
+package extractor_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+// validateSchema checks value against the subset of JSON Schema used by the output schema
+// It returns a description of every violation.
+func validateSchema(root, schema map[string]interface{}, value interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		definition, ok := root["definitions"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown reference %s", path, ref)}
+		}
+		return validateSchema(root, definition, value, path)
+	}
+
+	if types, ok := schema["type"]; ok {
+		allowed := []interface{}{types}
+		if list, ok := types.([]interface{}); ok {
+			allowed = list
+		}
+		valid := false
+		for _, t := range allowed {
+			valid = valid || hasSchemaType(value, t.(string))
+		}
+		if !valid {
+			return []string{fmt.Sprintf("%s: %v is not %v", path, value, types)}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		valid := false
+		for _, e := range enum {
+			valid = valid || e == value
+		}
+		if !valid {
+			return []string{fmt.Sprintf("%s: %v is not one of %v", path, value, enum)}
+		}
+	}
+
+	errs := []string{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: %s is missing", path, name))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				errs = append(errs, validateSchema(root, property, v[name], path+"."+name)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Sprintf("%s: %s is not in the schema", path, name))
+				}
+			case map[string]interface{}:
+				errs = append(errs, validateSchema(root, additional, v[name], path+"."+name)...)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				errs = append(errs, validateSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func hasSchemaType(value interface{}, schemaType string) bool {
+	switch v := value.(type) {
+	case nil:
+		return schemaType == "null"
+	case bool:
+		return schemaType == "boolean"
+	case string:
+		return schemaType == "string"
+	case float64:
+		return schemaType == "number" || (schemaType == "integer" && v == float64(int64(v)))
+	case []interface{}:
+		return schemaType == "array"
+	case map[string]interface{}:
+		return schemaType == "object"
+	}
+	return false
+}
+
+var _ = Describe("OutputSchema", func() {
+	var schema map[string]interface{}
+	var repo *testRepo
+	var outputDir string
+
+	BeforeEach(func() {
+		Expect(json.Unmarshal(extractor.OutputSchema(), &schema)).Should(Succeed())
+		repo = newTestRepo()
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	validate := func(definition string, value interface{}) []string {
+		return validateSchema(schema, map[string]interface{}{"$ref": "#/definitions/" + definition}, value, definition)
+	}
+
+	It("should describe the output", func() {
+		repo.git("remote", "add", "origin", "https://github.com/codersrank-org/repo_info_extractor.git")
+		repo.commit(testCommit{Files: map[string]string{
+			"main.go":   "package main\n\nimport \"github.com/onsi/ginkgo\"\n",
+			"README.md": "readme\n",
+		}})
+		repo.commit(testCommit{
+			Message: "Fix #1\n\nReviewed-by: Reviewer <reviewer@example.com>\n",
+			Files:   map[string]string{"main.go": "package main\n\nfunc main() {}\n"},
+			Deleted: []string{"README.md"},
+		})
+		repo.commit(testCommit{Name: "Other", Email: "other@example.com", Files: map[string]string{"other.go": "package main\n"}})
+
+		re := extractor.RepoExtractor{
+			RepoPath:           repo.Path,
+			OutputPath:         filepath.Join(outputDir, "repo_data"),
+			OutputFormat:       extractor.FormatJSONL,
+			Headless:           true,
+			UserEmails:         []string{"test@example.com"},
+			RecordBlobHashes:   true,
+			ComputeOwnership:   true,
+			ComputeLeaderboard: true,
+		}
+		Expect(re.Extract()).Should(Succeed())
+
+		file, err := os.Open(filepath.Join(outputDir, "repo_data_v2.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		lines := 0
+		for scanner.Scan() {
+			var value interface{}
+			Expect(json.Unmarshal(scanner.Bytes(), &value)).Should(Succeed())
+			definition := "commit"
+			if lines == 0 {
+				definition = "repo"
+			}
+			Expect(validate(definition, value)).To(BeEmpty())
+			lines++
+		}
+		Expect(scanner.Err()).ShouldNot(HaveOccurred())
+		Expect(lines).To(Equal(3))
+	})
+
+	It("should reject unknown fields", func() {
+		Expect(validate("changedFile", map[string]interface{}{
+			"fileName":   "main.go",
+			"insertions": 1.0,
+			"deletions":  0.0,
+			"language":   "Go",
+			"changeType": "added",
+			"unknown":    true,
+		})).To(Equal([]string{"changedFile: unknown is not in the schema"}))
+	})
+})
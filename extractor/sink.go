@@ -0,0 +1,137 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// CommitSink receives the commits of the user from ExtractStream
+type CommitSink interface {
+	Send(c *commit.Commit) error
+	// Close is called once every commit is sent
+	Close() error
+	// Abort is called instead of Close when the extraction fails, the commits sent are incomplete
+	Abort(err error)
+}
+
+// ExtractStream extracts the repo like ExtractContext, but sends the commits to sink instead of writing a file
+// Nothing is uploaded. The sink is closed when ExtractStream succeeds and aborted when it fails,
+// the first error of Send aborts the extraction.
+func (r *RepoExtractor) ExtractStream(ctx context.Context, sink CommitSink) (err error) {
+	defer func() {
+		if err != nil {
+			sink.Abort(err)
+			return
+		}
+		closeErr := sink.Close()
+		if closeErr != nil {
+			err = fmt.Errorf("cannot close the commit sink: %w", closeErr)
+		}
+	}()
+	return r.extract(ctx, func() error {
+		err := r.sendCommits(ctx, sink)
+		if err != nil {
+			return fmt.Errorf("step sendCommits: %w", err)
+		}
+		return nil
+	})
+}
+
+// sendCommits sends the commits of the user to sink until ctx is cancelled
+func (r *RepoExtractor) sendCommits(ctx context.Context, sink CommitSink) error {
+	for _, c := range r.userCommits {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := sink.Send(c)
+		if err != nil {
+			return fmt.Errorf("cannot send commit %s: %w", c.Hash, err)
+		}
+	}
+	return nil
+}
+
+// HTTPSink posts the commits as JSON lines in the chunked body of a single request
+// Send blocks while the server doesn't read, so a slow server slows the extraction down.
+type HTTPSink struct {
+	writer   *io.PipeWriter
+	response chan error
+}
+
+// NewHTTPSink starts the request to url, cancelling ctx aborts it
+// If token is not empty it is sent as a bearer token.
+func NewHTTPSink(ctx context.Context, url, token string) (*HTTPSink, error) {
+	reader, writer := io.Pipe()
+	request, err := http.NewRequestWithContext(ctx, "POST", url, reader)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Content-Type", "application/x-ndjson")
+	if token != "" {
+		request.Header.Add("Authorization", "Bearer "+token)
+	}
+
+	sink := &HTTPSink{writer: writer, response: make(chan error, 1)}
+	go func() {
+		err := doSinkRequest(request)
+		// Unblock Send if the request ends before the body is written
+		readErr := err
+		if readErr == nil {
+			readErr = errors.New("the server responded before the end of the commits")
+		}
+		reader.CloseWithError(readErr)
+		sink.response <- err
+	}()
+	return sink, nil
+}
+
+func doSinkRequest(request *http.Request) error {
+	client := &http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &UploadError{
+			StatusCode: response.StatusCode,
+			Response:   strings.TrimSpace(string(content)),
+		}
+	}
+	return nil
+}
+
+// Send writes the commit into the body of the request
+// If the request ended early its error is returned.
+func (s *HTTPSink) Send(c *commit.Commit) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = s.writer.Write(append(data, '\n'))
+	return err
+}
+
+// Close ends the body and waits for the response of the server
+func (s *HTTPSink) Close() error {
+	s.writer.Close()
+	return <-s.response
+}
+
+// Abort breaks off the body, so the server never receives a complete one, and waits for the request to end
+func (s *HTTPSink) Abort(err error) {
+	s.writer.CloseWithError(err)
+	<-s.response
+}
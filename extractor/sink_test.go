@@ -0,0 +1,150 @@
+package extractor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+// fakeSink records the commits and fails after failAfter commits if it is positive
+type fakeSink struct {
+	commits   []*commit.Commit
+	closed    int
+	aborted   error
+	failAfter int
+}
+
+func (s *fakeSink) Send(c *commit.Commit) error {
+	if s.failAfter > 0 && len(s.commits) == s.failAfter {
+		return errors.New("sink is full")
+	}
+	s.commits = append(s.commits, c)
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.closed++
+	return nil
+}
+
+func (s *fakeSink) Abort(err error) {
+	s.aborted = err
+}
+
+var _ = Describe("ExtractStream", func() {
+	var repo *testRepo
+	var hashes []string
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		hashes = nil
+		for i := 0; i < 5; i++ {
+			hashes = append(hashes, repo.commit(testCommit{Files: map[string]string{"main.go": fmt.Sprintf("package main // %d\n", i)}}))
+		}
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	newExtractor := func() *extractor.RepoExtractor {
+		return &extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			Headless:      true,
+			UserEmails:    []string{"test@example.com"},
+			SkipLibraries: true,
+			Deterministic: true,
+		}
+	}
+
+	It("should send every commit and close the sink", func() {
+		sink := &fakeSink{}
+		Expect(newExtractor().ExtractStream(context.Background(), sink)).Should(Succeed())
+		sent := []string{}
+		for _, c := range sink.commits {
+			sent = append(sent, c.Hash)
+		}
+		Expect(sent).To(Equal(hashes))
+		Expect(sink.closed).To(Equal(1))
+		Expect(sink.aborted).ShouldNot(HaveOccurred())
+	})
+
+	It("should stop at the first error of the sink", func() {
+		sink := &fakeSink{failAfter: 2}
+		err := newExtractor().ExtractStream(context.Background(), sink)
+		Expect(err).To(MatchError(ContainSubstring("sink is full")))
+		Expect(sink.commits).To(HaveLen(2))
+		Expect(sink.closed).To(Equal(0))
+		Expect(sink.aborted).To(Equal(err))
+	})
+
+	It("should abort the sink if the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		sink := &fakeSink{}
+		Expect(newExtractor().ExtractStream(ctx, sink)).To(MatchError(context.Canceled))
+		Expect(sink.commits).To(BeEmpty())
+		Expect(sink.closed).To(Equal(0))
+		Expect(sink.aborted).To(MatchError(context.Canceled))
+	})
+
+	It("should post the commits with the HTTP sink", func() {
+		var received []*commit.Commit
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer secret"))
+			metadata, commits := parseOutput(io.MultiReader(strings.NewReader("{}\n"), r.Body))
+			Expect(metadata).To(BeEmpty())
+			received = commits
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		sink, err := extractor.NewHTTPSink(context.Background(), server.URL, "secret")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(newExtractor().ExtractStream(context.Background(), sink)).Should(Succeed())
+		Expect(received).To(HaveLen(5))
+		Expect(received[4].Hash).To(Equal(hashes[4]))
+	})
+
+	It("should break off the body when the HTTP sink is aborted", func() {
+		readErr := make(chan error, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := ioutil.ReadAll(r.Body)
+			readErr <- err
+		}))
+		defer server.Close()
+
+		sink, err := extractor.NewHTTPSink(context.Background(), server.URL, "")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(sink.Send(&commit.Commit{Hash: hashes[0]})).Should(Succeed())
+		sink.Abort(errors.New("git log failed"))
+		Expect(<-readErr).Should(HaveOccurred())
+	})
+
+	It("should return the error of the server", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("invalid token\n"))
+		}))
+		defer server.Close()
+
+		sink, err := extractor.NewHTTPSink(context.Background(), server.URL, "")
+		Expect(err).ShouldNot(HaveOccurred())
+		err = newExtractor().ExtractStream(context.Background(), sink)
+		var uploadErr *extractor.UploadError
+		Expect(errors.As(err, &uploadErr)).To(BeTrue())
+		Expect(uploadErr.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+})
@@ -0,0 +1,66 @@
+package extractor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// DefaultSquashSizeFactor is used if SquashSizeFactor is zero
+const DefaultSquashSizeFactor = 10
+
+var (
+	// GitHub appends the number of the pull request to the subject of squash merges
+	squashSubjectRegex = regexp.MustCompile(`\(#\d+\)\s*$`)
+	bulletLineRegex    = regexp.MustCompile(`(?m)^\s*[*-]\s+\S`)
+)
+
+// flagSquashes sets IsLikelySquash on the commits which look like squash merges
+// A commit is likely a squash if its message says so, or if it changes both many more files
+// and many more lines than the median commit of the repo.
+func (r *RepoExtractor) flagSquashes(commits []*commit.Commit) {
+	factor := r.SquashSizeFactor
+	if factor == 0 {
+		factor = DefaultSquashSizeFactor
+	}
+	files := make([]int, 0, len(commits))
+	lines := make([]int, 0, len(commits))
+	for _, c := range commits {
+		files = append(files, len(c.ChangedFiles))
+		lines = append(lines, churn(c))
+	}
+	medianFiles := float64(medianInt(files))
+	medianLines := float64(medianInt(lines))
+	for _, c := range commits {
+		large := medianFiles > 0 && medianLines > 0 &&
+			float64(len(c.ChangedFiles)) > factor*medianFiles &&
+			float64(churn(c)) > factor*medianLines
+		c.IsLikelySquash = large || isSquashMessage(c)
+	}
+}
+
+// isSquashMessage tells squash merges by the pull request number in the subject
+// or by a body of several paragraphs listing the merged commits
+func isSquashMessage(c *commit.Commit) bool {
+	if squashSubjectRegex.MatchString(c.Subject) {
+		return true
+	}
+	paragraphs := strings.Count(strings.TrimSpace(c.Body), "\n\n") + 1
+	return paragraphs > 1 && len(bulletLineRegex.FindAllString(c.Body, -1)) > 1
+}
+
+// medianInt returns the median of the values, 0 for no values
+func medianInt(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,74 @@
+package extractor_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Squash detection", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		for i := 0; i < 5; i++ {
+			repo.commit(testCommit{Files: map[string]string{"main.go": fmt.Sprintf("package main\n\n// %d\n", i)}})
+		}
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	extract := func(detect bool, factor float64) map[string]bool {
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.DetectSquashes = detect
+			re.SquashSizeFactor = factor
+		})
+		squashes := map[string]bool{}
+		for _, c := range commits {
+			squashes[c.Subject] = c.IsLikelySquash
+		}
+		return squashes
+	}
+
+	It("should flag the pull request numbers in the subject", func() {
+		repo.commit(testCommit{Message: "Add the parser (#123)", Files: map[string]string{"parser.go": "package main\n"}})
+		squashes := extract(true, 0)
+		Expect(squashes["Add the parser (#123)"]).To(BeTrue())
+		Expect(squashes["commit 1"]).To(BeFalse())
+	})
+
+	It("should flag the bodies listing the squashed commits", func() {
+		repo.commit(testCommit{
+			Message: "Add the parser\n\n* Add the lexer\n\n* Add the grammar\n\n* Fix the tests\n",
+			Files:   map[string]string{"parser.go": "package main\n"},
+		})
+		repo.commit(testCommit{
+			Message: "Add the printer\n\nIt prints:\n- the tree\n",
+			Files:   map[string]string{"printer.go": "package main\n"},
+		})
+		squashes := extract(true, 0)
+		Expect(squashes["Add the parser"]).To(BeTrue())
+		Expect(squashes["Add the printer"]).To(BeFalse())
+	})
+
+	It("should flag the commits much larger than the median", func() {
+		files := map[string]string{}
+		for i := 0; i < 5; i++ {
+			files[fmt.Sprintf("file%d.go", i)] = "package main\n\nfunc main() {}\n"
+		}
+		repo.commit(testCommit{Message: "Large", Files: files})
+		Expect(extract(true, 0)["Large"]).To(BeFalse())
+		Expect(extract(true, 3)["Large"]).To(BeTrue())
+	})
+
+	It("should flag nothing by default", func() {
+		repo.commit(testCommit{Message: "Add the parser (#123)", Files: map[string]string{"parser.go": "package main\n"}})
+		Expect(extract(false, 0)).NotTo(ContainElement(true))
+	})
+})
@@ -0,0 +1,58 @@
+package extractor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// Dates of the commits feeding the time zone stats
+const (
+	DateSourceAuthor    = "author"    // When the change was written, rebases keep it
+	DateSourceCommitter = "committer" // When the commit was created, e.g. by a rebase on another machine
+)
+
+// TimeZoneCount is the number of commits made in a time zone
+type TimeZoneCount struct {
+	Offset  string `json:"offset"` // UTC offset, e.g. +0100
+	Commits int    `json:"commits"`
+}
+
+// validateTimeZoneSource returns an error for unknown TimeZoneSource values
+func (r *RepoExtractor) validateTimeZoneSource() error {
+	switch r.TimeZoneSource {
+	case "", DateSourceAuthor, DateSourceCommitter:
+		return nil
+	}
+	return fmt.Errorf("unknown time zone source %s", r.TimeZoneSource)
+}
+
+// timeZones counts the commits by the time zone of their author or committer dates, the most commits first
+// Commits without a parsable date are left out.
+func timeZones(commits []*commit.Commit, source string) []TimeZoneCount {
+	counts := map[string]int{}
+	for _, c := range commits {
+		date := c.Date
+		if source == DateSourceCommitter {
+			date = c.CommitterDate
+		}
+		t, err := time.Parse(dateFormat, date)
+		if err != nil {
+			continue
+		}
+		counts[t.Format("-0700")]++
+	}
+	zones := make([]TimeZoneCount, 0, len(counts))
+	for offset, n := range counts {
+		zones = append(zones, TimeZoneCount{Offset: offset, Commits: n})
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].Commits != zones[j].Commits {
+			return zones[i].Commits > zones[j].Commits
+		}
+		return zones[i].Offset < zones[j].Offset
+	})
+	return zones
+}
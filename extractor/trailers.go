@@ -0,0 +1,49 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// reviewerTrailers are the trailers naming the reviewers of a commit
+var reviewerTrailers = []string{"Reviewed-by", "Acked-by"}
+
+// coAuthorTrailers are the trailers naming the co-authors of a commit, e.g. when pair programming
+var coAuthorTrailers = []string{"Co-authored-by"}
+
+// identityTrailerRegex matches trailers like "Reviewed-by: Name <email>"
+var identityTrailerRegex = regexp.MustCompile(`^([A-Za-z-]+):\s*(.*?)\s*<([^>]+)>\s*$`)
+
+// parseIdentityTrailers returns the people from the trailers with the given keys
+// Keys are matched case insensitively, as git does.
+func parseIdentityTrailers(body string, keys []string) []commit.Author {
+	authors := []commit.Author{}
+	for _, line := range strings.Split(body, "\n") {
+		match := identityTrailerRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		for _, key := range keys {
+			if strings.EqualFold(match[1], key) {
+				authors = append(authors, commit.Author{
+					Name:  match[2],
+					Email: match[3],
+				})
+				break
+			}
+		}
+	}
+	return authors
+}
+
+// coAuthoredBy reports whether any co-author of c has one of the emails
+func coAuthoredBy(c *commit.Commit, emails map[string]bool) bool {
+	for _, coAuthor := range c.CoAuthors {
+		if emails[coAuthor.Email] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+	"github.com/codersrank-org/repo_info_extractor/obfuscation"
+)
+
+var _ = Describe("Trailers", func() {
+	var repo *testRepo
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+	})
+
+	It("should extract the reviewers", func() {
+		first := repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		hash := repo.commit(testCommit{
+			Message: "Fix the parser\n\nLonger description.\n\n" +
+				"Reviewed-by: Jane Doe <jane@example.com>\n" +
+				"acked-by: John Roe <john@example.com>\n" +
+				"Signed-off-by: Test User <test@example.com>\n",
+			Files: map[string]string{"main.go": "package main\n"},
+		})
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, hash)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits).To(HaveLen(1))
+		Expect(result.Commits[0].Reviewers).To(Equal([]commit.Author{
+			{Name: "Jane Doe", Email: "jane@example.com"},
+			{Name: "John Roe", Email: "john@example.com"},
+		}))
+		Expect(result.Commits[0].Subject).To(Equal("Fix the parser"))
+		Expect(result.Commits[0].Body).To(HavePrefix("Longer description.\n\nReviewed-by: Jane Doe"))
+		Expect(result.Commits[0].ChangedFiles).To(HaveLen(1))
+	})
+
+	It("should extract a co-author", func() {
+		first := repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		hash := repo.commit(testCommit{
+			Message: "Pair on the parser\n\nCo-authored-by: Jane Doe <jane@example.com>\n",
+			Files:   map[string]string{"main.go": "package main\n"},
+		})
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, hash)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits).To(HaveLen(1))
+		Expect(result.Commits[0].CoAuthors).To(Equal([]commit.Author{
+			{Name: "Jane Doe", Email: "jane@example.com"},
+		}))
+		Expect(result.Commits[0].Reviewers).To(BeEmpty())
+	})
+
+	It("should extract every co-author", func() {
+		first := repo.commit(testCommit{Files: map[string]string{"README.md": "readme\n"}})
+		hash := repo.commit(testCommit{
+			Message: "Mob on the parser\n\n" +
+				"Co-authored-by: Jane Doe <jane@example.com>\n" +
+				"co-authored-by: John Roe <john@example.com>\n" +
+				"Reviewed-by: Max Moe <max@example.com>\n",
+			Files: map[string]string{"main.go": "package main\n"},
+		})
+		re := extractor.RepoExtractor{RepoPath: repo.Path}
+		result, err := re.ExtractRange(first, hash)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Commits).To(HaveLen(1))
+		Expect(result.Commits[0].CoAuthors).To(Equal([]commit.Author{
+			{Name: "Jane Doe", Email: "jane@example.com"},
+			{Name: "John Roe", Email: "john@example.com"},
+		}))
+		Expect(result.Commits[0].Reviewers).To(Equal([]commit.Author{
+			{Name: "Max Moe", Email: "max@example.com"},
+		}))
+	})
+
+	It("should export the commits co-authored by the user", func() {
+		repo.commit(testCommit{Name: "Bob", Email: "bob@example.com", Files: map[string]string{"a.go": "package a\n"}})
+		paired := repo.commit(testCommit{
+			Name:    "Bob",
+			Email:   "bob@example.com",
+			Message: "Pair on b\n\nCo-authored-by: Jane Doe <jane@example.com>\n",
+			Files:   map[string]string{"b.go": "package b\n"},
+		})
+		own := repo.commit(testCommit{Name: "Jane Doe", Email: "jane@example.com", Files: map[string]string{"c.go": "package c\n"}})
+		for _, userCommitsOnly := range []bool{false, true} {
+			_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+				re.SkipLibraries = true
+				re.UserEmails = []string{"jane@example.com"}
+				re.UserCommitsOnly = userCommitsOnly
+			})
+			hashes := []string{}
+			for _, c := range commits {
+				hashes = append(hashes, c.Hash)
+			}
+			Expect(hashes).To(ConsistOf(paired, own))
+		}
+	})
+
+	It("should obfuscate the co-authors", func() {
+		repo.commit(testCommit{
+			Message: "Pair on the parser\n\nCo-authored-by: Jane Doe <jane@example.com>\n",
+			Files:   map[string]string{"main.go": "package main\n"},
+		})
+		_, commits := extractRepo(repo, func(re *extractor.RepoExtractor) {
+			re.SkipLibraries = true
+			re.Obfuscate = true
+		})
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].CoAuthors).To(Equal([]commit.Author{{
+			Name:  obfuscation.ObfuscateText("Jane Doe"),
+			Email: obfuscation.ObfuscateText("jane@example.com"),
+		}}))
+		Expect(commits[0].Body).To(BeEmpty())
+	})
+})
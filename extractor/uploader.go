@@ -11,12 +11,21 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
+// DefaultUploadURL is the CodersRank endpoint for private repo results
+const DefaultUploadURL = "https://grpcgateway.codersrank.io/candidate/privaterepo/Upload"
+
 // Upload result to CodersRAnk
 func Upload(path, repoName string) (string, error) {
+	return UploadTo(DefaultUploadURL, "", path, repoName)
+}
 
-	url := "https://grpcgateway.codersrank.io/candidate/privaterepo/Upload"
+// UploadTo uploads the result to the given url
+// If token is not empty it is sent as a bearer token.
+func UploadTo(url, token, path, repoName string) (string, error) {
 
 	// Read file
 	file, err := os.Open(path)
@@ -41,15 +50,15 @@ func Upload(path, repoName string) (string, error) {
 		return "", err
 	}
 	request.Header.Add("Content-Type", writer.FormDataContentType())
+	if token != "" {
+		request.Header.Add("Authorization", "Bearer "+token)
+	}
 
 	client := &http.Client{}
 	response, err := client.Do(request)
 	if err != nil {
 		return "", err
 	}
-	if response.StatusCode != http.StatusOK {
-		return "", errors.New(fmt.Sprintf("Server returned non 200 response. Error code: %d", response.StatusCode))
-	}
 	defer response.Body.Close()
 
 	content, err := ioutil.ReadAll(response.Body)
@@ -57,6 +66,13 @@ func Upload(path, repoName string) (string, error) {
 		return "", err
 	}
 
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return "", &UploadError{
+			StatusCode: response.StatusCode,
+			Response:   strings.TrimSpace(string(content)),
+		}
+	}
+
 	// Get response and return resulting token
 	var result uploadResponse
 	err = json.Unmarshal(content, &result)
@@ -68,6 +84,50 @@ func Upload(path, repoName string) (string, error) {
 	return processURL, nil
 }
 
+// UploadWithRetry calls UploadTo until it succeeds, at most attempts times
+// The delay doubles after every failed attempt. Client errors (4xx) are not retried.
+// The retries are logged to stderr.
+func UploadWithRetry(url, token, path, repoName string, attempts int, delay time.Duration) (string, error) {
+	r := &RepoExtractor{}
+	return r.uploadWithRetry(url, token, path, repoName, attempts, delay)
+}
+
+// uploadWithRetry is UploadWithRetry logging the retries to the Logger
+func (r *RepoExtractor) uploadWithRetry(url, token, path, repoName string, attempts int, delay time.Duration) (string, error) {
+	// A missing file won't appear by retrying
+	_, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var processURL string
+		processURL, err = UploadTo(url, token, path, repoName)
+		if err == nil {
+			return processURL, nil
+		}
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) && uploadErr.StatusCode < 500 {
+			return "", err
+		}
+		if attempt < attempts {
+			r.logf("Upload failed, retrying in %s. Error: %s", delay, err.Error())
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return "", err
+}
+
+// UploadError is returned if the server responds with a non 2xx status code
+type UploadError struct {
+	StatusCode int
+	Response   string
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("Server returned non 2xx response. Error code: %d, response: %s", e.StatusCode, e.Response)
+}
+
 type uploadResponse struct {
 	Token string `json:"token"`
 }
@@ -0,0 +1,281 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("Upload", func() {
+	var outputDir string
+	var zipPath string
+
+	BeforeEach(func() {
+		var err error
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		zipPath = filepath.Join(outputDir, "repo_data_v2.json.zip")
+		Expect(ioutil.WriteFile(zipPath, []byte("zip"), 0644)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outputDir)
+	})
+
+	It("should send the file with the token", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer secret"))
+			file, header, err := r.FormFile("file")
+			Expect(err).ShouldNot(HaveOccurred())
+			contents, _ := ioutil.ReadAll(file)
+			Expect(string(contents)).To(Equal("zip"))
+			Expect(header.Filename).To(Equal("repo_data_v2.json.zip"))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"token":"abc"}`))
+		}))
+		defer server.Close()
+
+		url, err := extractor.UploadTo(server.URL, "secret", zipPath, "repo")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(url).To(Equal("https://profile.codersrank.io/repo?token=abc&reponame=repo"))
+	})
+
+	It("should return the response for non 2xx responses", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("invalid token\n"))
+		}))
+		defer server.Close()
+
+		_, err := extractor.UploadTo(server.URL, "", zipPath, "repo")
+		Expect(err).Should(MatchError("Server returned non 2xx response. Error code: 401, response: invalid token"))
+	})
+
+	It("should upload after the extraction using the token from the environment", func() {
+		repo := newTestRepo()
+		defer repo.remove()
+		repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+
+		uploaded := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uploaded = r.Header.Get("Authorization") == "Bearer from-env"
+			w.Write([]byte(`{"token":"abc"}`))
+		}))
+		defer server.Close()
+		os.Setenv("CODERSRANK_TOKEN", "from-env")
+		defer os.Unsetenv("CODERSRANK_TOKEN")
+
+		re := extractor.RepoExtractor{
+			RepoPath:      repo.Path,
+			OutputPath:    filepath.Join(outputDir, "repo_data"),
+			SkipLibraries: true,
+			UserEmails:    []string{"test@example.com"},
+			UploadURL:     server.URL,
+			AssumeYes:     true,
+		}
+		Expect(re.Extract()).Should(Succeed())
+		Expect(uploaded).To(BeTrue())
+	})
+
+	Context("with confirmation", func() {
+		var repo *testRepo
+		var server *httptest.Server
+		var requests int
+
+		BeforeEach(func() {
+			repo = newTestRepo()
+			repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+			requests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Write([]byte(`{"token":"abc"}`))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+			repo.remove()
+		})
+
+		newExtractor := func() *extractor.RepoExtractor {
+			return &extractor.RepoExtractor{
+				RepoPath:      repo.Path,
+				OutputPath:    filepath.Join(outputDir, "repo_data"),
+				SkipLibraries: true,
+				UserEmails:    []string{"test@example.com"},
+				UploadURL:     server.URL,
+			}
+		}
+
+		It("should upload if the user agrees", func() {
+			defer extractor.SetIsTerminal(func() bool { return true })()
+			asked := ""
+			defer extractor.SetAskUpload(func(url string) bool {
+				asked = url
+				return true
+			})()
+			Expect(newExtractor().Extract()).Should(Succeed())
+			Expect(asked).To(Equal(server.URL))
+			Expect(requests).To(Equal(1))
+		})
+
+		It("should not upload if the user declines", func() {
+			defer extractor.SetIsTerminal(func() bool { return true })()
+			defer extractor.SetAskUpload(func(url string) bool { return false })()
+			Expect(newExtractor().Extract()).Should(Succeed())
+			Expect(requests).To(BeZero())
+			Expect(filepath.Join(outputDir, "repo_data_v2.json.zip")).To(BeARegularFile())
+		})
+
+		It("should not ask with AssumeYes", func() {
+			defer extractor.SetIsTerminal(func() bool { return true })()
+			defer extractor.SetAskUpload(func(url string) bool {
+				Fail("the upload shouldn't be confirmed")
+				return false
+			})()
+			re := newExtractor()
+			re.AssumeYes = true
+			Expect(re.Extract()).Should(Succeed())
+			Expect(requests).To(Equal(1))
+		})
+
+		It("should not upload without a terminal to ask", func() {
+			defer extractor.SetIsTerminal(func() bool { return false })()
+			Expect(newExtractor().Extract()).Should(Succeed())
+			Expect(requests).To(BeZero())
+		})
+	})
+
+	table.DescribeTable("should upload depending on Headless, Upload and NoUpload",
+		func(headless, upload, noUpload, uploaded bool) {
+			repo := newTestRepo()
+			defer repo.remove()
+			repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Write([]byte(`{"token":"abc"}`))
+			}))
+			defer server.Close()
+
+			re := extractor.RepoExtractor{
+				RepoPath:      repo.Path,
+				OutputPath:    filepath.Join(outputDir, "repo_data"),
+				SkipLibraries: true,
+				UserEmails:    []string{"test@example.com"},
+				UploadURL:     server.URL,
+				Headless:      headless,
+				Upload:        upload,
+				NoUpload:      noUpload,
+				AssumeYes:     true,
+			}
+			Expect(re.Extract()).Should(Succeed())
+			if uploaded {
+				Expect(requests).To(Equal(1))
+			} else {
+				Expect(requests).To(BeZero())
+			}
+		},
+		table.Entry("interactive", false, false, false, true),
+		table.Entry("interactive with Upload", false, true, false, true),
+		table.Entry("headless", true, false, false, false),
+		table.Entry("headless with Upload", true, true, false, true),
+		table.Entry("interactive with NoUpload", false, false, true, false),
+		table.Entry("headless with Upload and NoUpload", true, true, true, false),
+	)
+
+	Context("with retries", func() {
+		It("should retry server errors", func() {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				requests++
+				file, _, err := r.FormFile("file")
+				Expect(err).ShouldNot(HaveOccurred())
+				contents, _ := ioutil.ReadAll(file)
+				Expect(string(contents)).To(Equal("zip"))
+				if requests < 3 {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.Write([]byte(`{"token":"abc"}`))
+			}))
+			defer server.Close()
+
+			url, err := extractor.UploadWithRetry(server.URL, "", zipPath, "repo", 3, time.Millisecond)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(url).To(ContainSubstring("token=abc"))
+			Expect(requests).To(Equal(3))
+		})
+
+		It("should log the retries of the extraction", func() {
+			repo := newTestRepo()
+			defer repo.remove()
+			repo.commit(testCommit{Files: map[string]string{"main.go": "package main\n"}})
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests < 2 {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.Write([]byte(`{"token":"abc"}`))
+			}))
+			defer server.Close()
+
+			buffer := gbytes.NewBuffer()
+			re := &extractor.RepoExtractor{
+				RepoPath:         repo.Path,
+				OutputPath:       filepath.Join(outputDir, "repo_data"),
+				Headless:         true,
+				Upload:           true,
+				SkipLibraries:    true,
+				UserEmails:       []string{"test@example.com"},
+				UploadURL:        server.URL,
+				UploadRetryDelay: time.Millisecond,
+				Logger:           bufferLogger(buffer),
+			}
+			Expect(re.Extract()).Should(Succeed())
+			Expect(requests).To(Equal(2))
+			Expect(string(buffer.Contents())).To(ContainSubstring("Upload failed, retrying in 1ms."))
+		})
+
+		It("should give up after the last attempt", func() {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			_, err := extractor.UploadWithRetry(server.URL, "", zipPath, "repo", 2, time.Millisecond)
+			Expect(err).Should(HaveOccurred())
+			Expect(requests).To(Equal(2))
+		})
+
+		It("should not retry client errors", func() {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusBadRequest)
+			}))
+			defer server.Close()
+
+			_, err := extractor.UploadWithRetry(server.URL, "", zipPath, "repo", 3, time.Millisecond)
+			Expect(err).Should(HaveOccurred())
+			Expect(requests).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,127 @@
+package extractor
+
+import (
+	"context"
+	"strings"
+)
+
+// initAuthorFilter sets the emails which git log filters the commits by if UserCommitsOnly is set
+// The emails must be known and no option may need the commits of the other authors, otherwise
+// every commit is read. The emails of .mailmap and AuthorMappingPath mapping to the user are kept.
+func (r *RepoExtractor) initAuthorFilter() error {
+	r.authorFilter = nil
+	if !r.UserCommitsOnly || r.LogSource != nil || len(r.UserEmails) == 0 {
+		return nil
+	}
+	var needsEveryCommit string
+	switch {
+	case len(r.Seed) > 0 && r.Headless:
+		needsEveryCommit = "Seed"
+	case r.ComputeOwnership:
+		needsEveryCommit = "ComputeOwnership"
+	case r.ComputeLeaderboard:
+		needsEveryCommit = "ComputeLeaderboard"
+	case r.DetectSquashes:
+		needsEveryCommit = "DetectSquashes"
+	case r.MaxCommits > 0:
+		needsEveryCommit = "MaxCommits"
+	}
+	if needsEveryCommit != "" {
+		r.logf("Reading the commits of every author, %s needs them", needsEveryCommit)
+		return nil
+	}
+
+	// git log matches the authors mapped by .mailmap
+	emails, err := r.canonicalEmails(r.UserEmails)
+	if err != nil {
+		return err
+	}
+	emails = append(emails, r.UserEmails...)
+	if r.AuthorMappingPath != "" {
+		mapping, err := readAuthorMapping(r.AuthorMappingPath)
+		if err != nil {
+			return err
+		}
+		emails = append(emails, unmapEmails(emails, mapping)...)
+	}
+
+	filter := []string{}
+	seen := map[string]bool{}
+	for _, email := range emails {
+		key := strings.ToLower(email)
+		if !seen[key] {
+			seen[key] = true
+			filter = append(filter, email)
+		}
+	}
+
+	// git log can't match the author or the trailers at once, co-authored commits need every commit
+	coAuthored, err := r.mentionsEmails(filter)
+	if err != nil {
+		return err
+	}
+	if coAuthored {
+		r.logf("Reading the commits of every author, the user co-authored some commits")
+		return nil
+	}
+	r.authorFilter = filter
+	return nil
+}
+
+// mentionsEmails reports whether the message of any commit contains one of the emails
+// It may find the emails in other trailers too, which only costs reading every commit.
+func (r *RepoExtractor) mentionsEmails(emails []string) (bool, error) {
+	args := []string{"log", "--no-merges", "--max-count=1", "--pretty=format:%H", "--fixed-strings", "--regexp-ignore-case"}
+	for _, email := range emails {
+		args = append(args, "--grep=<"+email+">")
+	}
+	args = append(args, r.revisionArgs()...)
+	out, err := r.runGit(append(args, "--")...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// unmapEmails returns the raw emails of the mapping whose canonical email is one of emails
+func unmapEmails(emails []string, mapping map[string]authorOverride) []string {
+	canonical := map[string]bool{}
+	for _, email := range emails {
+		canonical[strings.ToLower(email)] = true
+	}
+	raw := []string{}
+	for email, override := range mapping {
+		if canonical[strings.ToLower(override.Email)] {
+			raw = append(raw, email)
+		}
+	}
+	return raw
+}
+
+// authorArgs returns the arguments of git log reading only the commits of authorFilter
+// The emails are matched literally, ignoring the case like the emails of the author mapping.
+func (r *RepoExtractor) authorArgs() []string {
+	if len(r.authorFilter) == 0 {
+		return nil
+	}
+	args := []string{"--fixed-strings", "--regexp-ignore-case"}
+	if r.SkipMailmap {
+		args = append(args, "--no-use-mailmap")
+	} else {
+		args = append(args, "--use-mailmap")
+	}
+	for _, email := range r.authorFilter {
+		args = append(args, "--author=<"+email+">")
+	}
+	return args
+}
+
+// hasCommits reports whether git log without authorArgs reads any commit
+func (r *RepoExtractor) hasCommits(ctx context.Context) (bool, error) {
+	args := append([]string{"log", "--no-merges", "--max-count=1", "--pretty=format:%H"}, r.revisionArgs()...)
+	out, err := r.runGitContext(ctx, append(args, "--")...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
@@ -0,0 +1,153 @@
+package extractor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+	"github.com/codersrank-org/repo_info_extractor/extractor"
+)
+
+var _ = Describe("UserCommitsOnly", func() {
+	var repo *testRepo
+	var outputDir, argsPath string
+	var buffer *gbytes.Buffer
+
+	BeforeEach(func() {
+		repo = newTestRepo()
+		mailmap, err := ioutil.ReadFile("./fixtures/mailmap")
+		Expect(err).ShouldNot(HaveOccurred())
+		repo.commit(testCommit{Name: "Alice Smith", Email: "alice@example.com", Files: map[string]string{".mailmap": string(mailmap)}})
+		repo.commit(testCommit{Name: "alice", Email: "alice@old.example.com", Files: map[string]string{"a.go": "package a\n"}})
+		repo.commit(testCommit{Name: "Bob", Email: "bob@example.com", Files: map[string]string{"b.go": "package b\n"}})
+		repo.commit(testCommit{Name: "Alice", Email: "ALICE@laptop.local", Files: map[string]string{"c.py": "import os\n"}})
+		repo.commit(testCommit{Name: "Carol", Email: "carol@example.com", Files: map[string]string{"d.go": "package d\n"}})
+		repo.commit(testCommit{Name: "Bob", Email: "bob@example.com", Files: map[string]string{"e.go": "package e\n"}})
+		outputDir, err = ioutil.TempDir("", "repo_info_extractor_output")
+		Expect(err).ShouldNot(HaveOccurred())
+		argsPath = filepath.Join(outputDir, "args")
+		buffer = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		repo.remove()
+		os.RemoveAll(outputDir)
+	})
+
+	// newExtractor runs git through a wrapper recording the arguments of every git command
+	newExtractor := func(emails ...string) *extractor.RepoExtractor {
+		wrapper := filepath.Join(outputDir, "git")
+		script := "#!/bin/sh\necho \"$@\" >> " + argsPath + "\nexec git \"$@\"\n"
+		Expect(ioutil.WriteFile(wrapper, []byte(script), 0755)).Should(Succeed())
+		return &extractor.RepoExtractor{
+			RepoPath:   repo.Path,
+			GitPath:    wrapper,
+			OutputPath: filepath.Join(outputDir, "repo_data"),
+			Headless:   true,
+			UserEmails: emails,
+			Logger:     bufferLogger(buffer),
+		}
+	}
+
+	extract := func(re *extractor.RepoExtractor) ([]*commit.Commit, map[string]interface{}) {
+		os.Remove(argsPath)
+		Expect(re.Extract()).Should(Succeed())
+		metadata, commits := readOutput(filepath.Join(outputDir, "repo_data_v2.json.zip"))
+		return commits, metadata
+	}
+
+	// logArgs returns the arguments of the git logs reading the commits
+	logArgs := func() []string {
+		content, err := ioutil.ReadFile(argsPath)
+		Expect(err).ShouldNot(HaveOccurred())
+		logs := []string{}
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.Contains(line, " log --numstat") {
+				logs = append(logs, line)
+			}
+		}
+		Expect(logs).NotTo(BeEmpty())
+		return logs
+	}
+
+	hashes := func(commits []*commit.Commit) []string {
+		res := []string{}
+		for _, c := range commits {
+			res = append(res, c.Hash)
+		}
+		return res
+	}
+
+	It("should read the same commits of the user as the full scan", func() {
+		everyCommit, _ := extract(newExtractor("alice@old.example.com"))
+		for _, args := range logArgs() {
+			Expect(args).NotTo(ContainSubstring("--author"))
+		}
+
+		re := newExtractor("alice@old.example.com")
+		re.UserCommitsOnly = true
+		userCommits, metadata := extract(re)
+		for _, args := range logArgs() {
+			Expect(args).To(ContainSubstring("--author=<alice@old.example.com>"))
+			Expect(args).To(ContainSubstring("--author=<alice@example.com>"))
+		}
+
+		// The mailmap maps every commit of Alice to alice@example.com
+		Expect(everyCommit).To(HaveLen(3))
+		Expect(hashes(userCommits)).To(ConsistOf(hashes(everyCommit)))
+		Expect(metadata["languagesIntroduced"]).To(BeEmpty())
+	})
+
+	It("should read the commits of the raw emails of the author mapping", func() {
+		mappingPath := filepath.Join(outputDir, "authors.csv")
+		Expect(ioutil.WriteFile(mappingPath, []byte("carol@example.com,carol@work.example.com\n"), 0644)).Should(Succeed())
+		re := newExtractor("carol@work.example.com")
+		re.AuthorMappingPath = mappingPath
+		re.UserCommitsOnly = true
+		commits, _ := extract(re)
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0].AuthorEmail).To(Equal("carol@work.example.com"))
+	})
+
+	It("should export no commits if the user has none", func() {
+		re := newExtractor("nobody@example.com")
+		re.UserCommitsOnly = true
+		commits, _ := extract(re)
+		Expect(commits).To(BeEmpty())
+	})
+
+	It("should read every commit if an option needs the other authors", func() {
+		re := newExtractor("bob@example.com")
+		re.UserCommitsOnly = true
+		re.ComputeLeaderboard = true
+		commits, _ := extract(re)
+		Expect(commits).To(HaveLen(2))
+		for _, args := range logArgs() {
+			Expect(args).NotTo(ContainSubstring("--author"))
+		}
+		Expect(string(buffer.Contents())).To(ContainSubstring("Reading the commits of every author, ComputeLeaderboard needs them"))
+	})
+
+	It("should read every commit if the user co-authored some", func() {
+		repo.commit(testCommit{
+			Name:    "Bob",
+			Email:   "bob@example.com",
+			Message: "Pair on f\n\nCo-authored-by: Carol <carol@example.com>\n",
+			Files:   map[string]string{"f.go": "package f\n"},
+		})
+		re := newExtractor("carol@example.com")
+		re.UserCommitsOnly = true
+		commits, _ := extract(re)
+		Expect(commits).To(HaveLen(2))
+		for _, args := range logArgs() {
+			Expect(args).NotTo(ContainSubstring("--author"))
+		}
+		Expect(string(buffer.Contents())).To(ContainSubstring("Reading the commits of every author, the user co-authored some commits"))
+	})
+})
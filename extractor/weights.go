@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/commit"
+)
+
+// defaultPathWeight is the weight of the files which no PathWeights pattern matches
+const defaultPathWeight = 1.0
+
+// validatePathWeights returns an error for invalid PathWeights patterns
+func (r *RepoExtractor) validatePathWeights() error {
+	for pattern := range r.PathWeights {
+		_, err := path.Match(strings.TrimSuffix(pattern, "/"), "")
+		if err != nil {
+			return fmt.Errorf("invalid path weight pattern %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// pathWeight returns the highest weight of the patterns matching the path like ExcludePatterns
+func pathWeight(filePath string, weights map[string]float64) float64 {
+	weight, matched := defaultPathWeight, false
+	for pattern, w := range weights {
+		if !isExcluded(filePath, []string{strings.TrimSuffix(pattern, "/")}) {
+			continue
+		}
+		if !matched || w > weight {
+			weight, matched = w, true
+		}
+	}
+	return weight
+}
+
+// setWeightedScores sets the changed lines of the commits weighted by PathWeights
+// and returns the sum of the scores
+func setWeightedScores(commits []*commit.Commit, weights map[string]float64) float64 {
+	total := 0.0
+	for _, c := range commits {
+		c.WeightedScore = 0
+		for _, f := range c.ChangedFiles {
+			c.WeightedScore += float64(f.Insertions+f.Deletions) * pathWeight(f.Path, weights)
+		}
+		total += c.WeightedScore
+	}
+	return total
+}
@@ -0,0 +1,48 @@
+package extractor
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// initMainRepoDir finds the main repo of a linked worktree, created by git worktree add
+// The git directory of a worktree differs from the common directory of the repo, which git
+// rev-parse tells. Outside of a linked worktree the main repo dir stays empty.
+func (r *RepoExtractor) initMainRepoDir() {
+	r.mainRepoDir = ""
+	out, err := r.runGit("rev-parse", "--git-dir", "--git-common-dir")
+	if err != nil {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		return
+	}
+	gitDir, commonDir := r.absGitPath(lines[0]), r.absGitPath(lines[1])
+	if gitDir == commonDir {
+		return
+	}
+	r.debugf("%s is a worktree of %s", r.repoDir(), commonDir)
+	// The common directory is the .git of the main repo, or the bare repo itself
+	if filepath.Base(commonDir) == ".git" {
+		commonDir = filepath.Dir(commonDir)
+	}
+	r.mainRepoDir = commonDir
+}
+
+// absGitPath returns the absolute path of a path printed by git rev-parse
+// Relative paths are relative to the directory git runs in.
+func (r *RepoExtractor) absGitPath(path string) string {
+	if !filepath.IsAbs(path) {
+		dir, _ := r.gitArgs(nil)
+		path = filepath.Join(dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	// Temporary directories can be symlinks, e.g. on macOS
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
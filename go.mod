@@ -9,6 +9,7 @@ require (
 	github.com/frankban/quicktest v1.11.3 // indirect
 	github.com/golang/snappy v0.0.1 // indirect
 	github.com/iancoleman/orderedmap v0.2.0
+	github.com/mattn/go-isatty v0.0.12
 	github.com/mholt/archiver v3.1.1+incompatible
 	github.com/nwaples/rardecode v1.1.0 // indirect
 	github.com/onsi/ginkgo v1.15.1
@@ -18,5 +19,4 @@ require (
 	github.com/ulikunitz/xz v0.5.7 // indirect
 	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8 // indirect
 	golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb
-	golang.org/x/text v0.3.3
 )
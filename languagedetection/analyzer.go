@@ -1,7 +1,11 @@
 package languagedetection
 
 import (
+	"bufio"
+	"bytes"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/src-d/enry/v2"
 )
@@ -27,14 +31,58 @@ func (l *LanguageAnalyzer) DetectLanguageFromExtension(extension string) string
 	return ""
 }
 
+// DetectLanguageFromFileName returns programming language of well-known files without a telling extension
+// like Dockerfile, Makefile or CMakeLists.txt
+func (l *LanguageAnalyzer) DetectLanguageFromFileName(fileName string) string {
+	return fileNameMap[fileName]
+}
+
+// DetectLanguageFromShebang returns programming language based on the interpreter of the first line,
+// like #!/usr/bin/env python
+func (l *LanguageAnalyzer) DetectLanguageFromShebang(fileContents []byte) string {
+	line, err := bufio.NewReader(bytes.NewReader(fileContents)).ReadString('\n')
+	if err != nil && line == "" {
+		return ""
+	}
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip the options of env, like -S
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	// python3.8 is python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return interpreterMap[interpreter]
+}
+
 // DetectLanguageFromFile returns programming language based on file itself
 // It also needs filename to increase accuracy
 func (l *LanguageAnalyzer) DetectLanguageFromFile(filePath string, fileContents []byte) string {
+	// enry can't tell MATLAB from Objective-C without the other files of the repo
+	extension := strings.ToLower(filepath.Ext(filePath))
+	if extension == ".m" {
+		if objectiveCRegex.Match(fileContents) {
+			return "Objective-C"
+		}
+		return "MATLAB"
+	}
 	lang, _ := enry.GetLanguageByContent(filePath, fileContents)
 	// For some reason enry is too bad at detecting Perl files
 	// However it can successfully detect Prolog files
 	// So, if the extension is "pl" but enry couldn't detect the language, it is probably Perl
-	if filepath.Ext(filePath) == ".pl" && lang == "" {
+	if extension == ".pl" && lang == "" {
 		return "Perl"
 	}
 	return lang
@@ -57,6 +105,37 @@ func buildExtensionToLanguageMap(input map[string][]string) map[string]string {
 	return extensionMap
 }
 
+// objectiveCRegex matches the directives which only Objective-C files of the .m files have
+var objectiveCRegex = regexp.MustCompile(`(?m)^\s*(@interface|@implementation|@protocol|#import)\b`)
+
+// fileNameMap maps the names of well-known files to their language
+var fileNameMap = map[string]string{
+	"CMakeLists.txt": "CMake",
+	"Dockerfile":     "Dockerfile",
+	"GNUmakefile":    "Makefile",
+	"Gemfile":        "Ruby",
+	"Jenkinsfile":    "Groovy",
+	"Makefile":       "Makefile",
+	"Rakefile":       "Ruby",
+	"Vagrantfile":    "Ruby",
+	"makefile":       "Makefile",
+}
+
+// interpreterMap maps the interpreters of the shebangs to the languages
+var interpreterMap = map[string]string{
+	"bash":    "Shell",
+	"dash":    "Shell",
+	"ksh":     "Shell",
+	"node":    "JavaScript",
+	"perl":    "Perl",
+	"php":     "PHP",
+	"python":  "Python",
+	"ruby":    "Ruby",
+	"sh":      "Shell",
+	"ts-node": "TypeScript",
+	"zsh":     "Shell",
+}
+
 var extensionsWithMultipleLanguages = map[string]bool{
 	"m":  true, // Objective-C, Matlab
 	"pl": true, // Perl, Prolog
@@ -0,0 +1,49 @@
+package languagedetection_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/codersrank-org/repo_info_extractor/languagedetection"
+)
+
+var _ = Describe("LanguageAnalyzer", func() {
+	analyzer := languagedetection.NewLanguageAnalyzer()
+
+	table.DescribeTable("should detect the well-known files by their names",
+		func(fileName, language string) {
+			Expect(analyzer.DetectLanguageFromFileName(fileName)).To(Equal(language))
+		},
+		table.Entry("Dockerfile", "Dockerfile", "Dockerfile"),
+		table.Entry("Makefile", "Makefile", "Makefile"),
+		table.Entry("Rakefile", "Rakefile", "Ruby"),
+		table.Entry("Gemfile", "Gemfile", "Ruby"),
+		table.Entry("CMakeLists.txt", "CMakeLists.txt", "CMake"),
+		table.Entry("other files", "LICENSE", ""),
+	)
+
+	table.DescribeTable("should detect the scripts by their shebangs",
+		func(contents, language string) {
+			Expect(analyzer.DetectLanguageFromShebang([]byte(contents))).To(Equal(language))
+		},
+		table.Entry("env", "#!/usr/bin/env python\nprint('hello')\n", "Python"),
+		table.Entry("versioned interpreter", "#!/usr/bin/env python3.8\n", "Python"),
+		table.Entry("env options", "#!/usr/bin/env -S node --harmony\n", "JavaScript"),
+		table.Entry("interpreter path", "#!/bin/bash -e\necho hello\n", "Shell"),
+		table.Entry("single line", "#! /usr/bin/perl", "Perl"),
+		table.Entry("unknown interpreter", "#!/usr/bin/awk -f\n", ""),
+		table.Entry("no shebang", "echo hello\n", ""),
+		table.Entry("empty file", "", ""),
+	)
+
+	table.DescribeTable("should tell Objective-C and MATLAB .m files apart",
+		func(contents, language string) {
+			Expect(analyzer.DetectLanguageFromFile("src/file.m", []byte(contents))).To(Equal(language))
+		},
+		table.Entry("#import", "#import <Foundation/Foundation.h>\n\nint main() { return 0; }\n", "Objective-C"),
+		table.Entry("@interface", "// Shape\n@interface Shape : NSObject\n@end\n", "Objective-C"),
+		table.Entry("@implementation", "@implementation Shape\n@end\n", "Objective-C"),
+		table.Entry("MATLAB function", "function y = square(x)\n% Squares x\n  y = x .^ 2;\nend\n", "MATLAB"),
+	)
+})
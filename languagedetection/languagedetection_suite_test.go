@@ -0,0 +1,13 @@
+package languagedetection_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLanguageDetection(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Language Detection Suite")
+}
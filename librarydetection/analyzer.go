@@ -2,6 +2,9 @@ package librarydetection
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // Analyzer is an interface for extracting various features from files
@@ -29,3 +32,51 @@ func GetAnalyzer(language string) (Analyzer, error) {
 func AddAnalyzer(language string, analyzer Analyzer) {
 	analyzers[language] = analyzer
 }
+
+// fileAnalyzer extracts the libraries of a specific file, like a package manifest
+type fileAnalyzer struct {
+	language string
+	analyzer Analyzer
+}
+
+var fileAnalyzers = map[string]fileAnalyzer{}
+
+// filePatternAnalyzers are the file analyzers added with a pattern like "*.gemspec"
+var filePatternAnalyzers = map[string]fileAnalyzer{}
+
+// GetFileAnalyzer returns the analyzer for files with the given name
+// and the language its libraries belong to
+// The names have precedence over the patterns, the patterns are tried in lexical order.
+func GetFileAnalyzer(fileName string) (Analyzer, string, error) {
+	fa, ok := fileAnalyzers[fileName]
+	if ok {
+		return fa.analyzer, fa.language, nil
+	}
+	patterns := make([]string, 0, len(filePatternAnalyzers))
+	for pattern := range filePatternAnalyzers {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, fileName); matched {
+			fa = filePatternAnalyzers[pattern]
+			return fa.analyzer, fa.language, nil
+		}
+	}
+	return nil, "", fmt.Errorf("no analyzer for %s exists", fileName)
+}
+
+// AddFileAnalyzer allows users to add analyzers for files with a specific name, like "Package.swift"
+// or with a name matching a pattern of filepath.Match, like "*.gemspec".
+// The libraries are recorded for the given language.
+func AddFileAnalyzer(fileName, language string, analyzer Analyzer) {
+	fa := fileAnalyzer{
+		language: language,
+		analyzer: analyzer,
+	}
+	if strings.ContainsAny(fileName, "*?[") {
+		filePatternAnalyzers[fileName] = fa
+		return
+	}
+	fileAnalyzers[fileName] = fa
+}
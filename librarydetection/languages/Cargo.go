@@ -0,0 +1,46 @@
+package languages
+
+import (
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewCargoAnalyzer constructor for Cargo.toml manifests
+// The libraries are the declared crates, like "serde".
+func NewCargoAnalyzer() librarydetection.Analyzer {
+	return &cargoAnalyzer{}
+}
+
+type cargoAnalyzer struct{}
+
+var cargoDependencyTables = []string{"dependencies", "dev-dependencies", "build-dependencies"}
+
+func (a *cargoAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// [dependencies], [target.'cfg(unix)'.dev-dependencies] and [workspace.dependencies] list the crates as keys,
+	// [dependencies.serde] declares a single crate
+	var res []string
+	seen := map[string]bool{}
+	add := func(crate string) {
+		if crate != "" && !seen[crate] {
+			seen[crate] = true
+			res = append(res, crate)
+		}
+	}
+	for _, table := range parseTOMLTables(contents) {
+		for _, kind := range cargoDependencyTables {
+			if table.name == kind || strings.HasSuffix(table.name, "."+kind) {
+				for _, key := range table.keys {
+					add(key)
+				}
+				break
+			}
+			i := strings.LastIndex(table.name, kind+".")
+			if i == 0 || i > 0 && table.name[i-1] == '.' {
+				add(strings.Trim(table.name[i+len(kind)+1:], `"'`))
+				break
+			}
+		}
+	}
+	return res, nil
+}
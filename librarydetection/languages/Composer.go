@@ -0,0 +1,41 @@
+package languages
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewComposerAnalyzer constructor for composer.json manifests
+// The libraries are the required packages, like "laravel/framework".
+func NewComposerAnalyzer() librarydetection.Analyzer {
+	return &composerAnalyzer{}
+}
+
+type composerAnalyzer struct{}
+
+type composerManifest struct {
+	Require    map[string]string `json:"require"`
+	RequireDev map[string]string `json:"require-dev"`
+}
+
+func (a *composerAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	var manifest composerManifest
+	err := json.Unmarshal([]byte(contents), &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, requirements := range []map[string]string{manifest.Require, manifest.RequireDev} {
+		for name := range requirements {
+			// the platform packages like php and ext-json have no vendor
+			if !strings.Contains(name, "/") {
+				continue
+			}
+			res = append(res, name)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,33 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("ComposerLibraryDetection", func() {
+	fixture, err := ioutil.ReadFile("./fixtures/composer.fixture")
+	if err != nil {
+		panic(err)
+	}
+
+	analyzer := languages.NewComposerAnalyzer()
+
+	Describe("Extract composer.json Libraries", func() {
+		It("Should be able to extract the required packages", func() {
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{
+				"guzzlehttp/guzzle",
+				"laravel/framework",
+				"phpunit/phpunit",
+				"mockery/mockery",
+			})
+		})
+	})
+})
@@ -0,0 +1,29 @@
+package languages
+
+import (
+	"regexp"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewGemfileAnalyzer constructor for Gemfile and *.gemspec manifests
+func NewGemfileAnalyzer() librarydetection.Analyzer {
+	return &gemfileAnalyzer{}
+}
+
+type gemfileAnalyzer struct{}
+
+func (a *gemfileAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// gems of a Gemfile like gem 'rails', '~> 6.0'
+	regexGem, err := regexp.Compile(`(?m)^\s*gem(?:\s+|\s*\(\s*)["']([^"']+)["']`)
+	if err != nil {
+		return nil, err
+	}
+	// dependencies of a gemspec like spec.add_development_dependency "rspec"
+	regexDependency, err := regexp.Compile(`(?m)^\s*\w+\.add_(?:runtime_|development_)?dependency(?:\s+|\s*\(\s*)["']([^"']+)["']`)
+	if err != nil {
+		return nil, err
+	}
+
+	return executeRegexes(contents, []*regexp.Regexp{regexGem, regexDependency}), nil
+}
@@ -0,0 +1,30 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("GemfileLibraryDetection", func() {
+	analyzer := languages.NewGemfileAnalyzer()
+
+	table.DescribeTable("Should be able to extract libraries",
+		func(fixturePath string, expectedLibraries []string) {
+			fixture, err := ioutil.ReadFile(fixturePath)
+			if err != nil {
+				panic(err)
+			}
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, expectedLibraries)
+		},
+		table.Entry("Gemfile", "./fixtures/gemfile.fixture", []string{"rails", "pg", "puma", "rspec-rails", "debug"}),
+		table.Entry("gemspec", "./fixtures/gemspec.fixture", []string{"thor", "zeitwerk", "rake"}),
+	)
+})
@@ -3,6 +3,7 @@ package languages
 import (
 	"github.com/codersrank-org/repo_info_extractor/librarydetection"
 	"regexp"
+	"strings"
 )
 
 // NewGoAnalyzer constructor
@@ -61,5 +62,20 @@ func (a *goAnalyzer) ExtractLibraries(contents string) ([]string, error) {
 
 	allLibs = append(allLibs, executeRegexes(contents, regexes)...)
 
-	return allLibs, nil
+	// The standard library is not a dependency
+	libs := make([]string, 0, len(allLibs))
+	for _, lib := range allLibs {
+		if !isGoStandardLibrary(lib) {
+			libs = append(libs, lib)
+		}
+	}
+
+	return libs, nil
+}
+
+// isGoStandardLibrary returns true for the packages of the standard library and cgo's "C"
+// Only the paths of the standard library have no dot in their first element, e.g. "fmt" or "net/http".
+func isGoStandardLibrary(importPath string) bool {
+	firstElement := strings.SplitN(importPath, "/", 2)[0]
+	return !strings.Contains(firstElement, ".")
 }
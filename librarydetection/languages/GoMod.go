@@ -0,0 +1,57 @@
+package languages
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewGoModAnalyzer constructor for go.mod manifests
+// The libraries are the required modules, like "github.com/onsi/ginkgo".
+func NewGoModAnalyzer() librarydetection.Analyzer {
+	return &goModAnalyzer{}
+}
+
+type goModAnalyzer struct{}
+
+func (a *goModAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// require github.com/pkg/errors v0.9.1
+	// require (
+	//     golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb
+	//     github.com/golang/snappy v0.0.1 // indirect
+	// )
+	var res []string
+	inRequire := false
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// the indirect requirements are the dependencies of the dependencies
+		if strings.Contains(line, "// indirect") {
+			continue
+		}
+		if i := strings.Index(line, "//"); i != -1 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if inRequire {
+			if line == ")" {
+				inRequire = false
+				continue
+			}
+		} else {
+			if !strings.HasPrefix(line, "require") {
+				continue
+			}
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require"))
+			if line == "(" {
+				inRequire = true
+				continue
+			}
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			res = append(res, strings.Trim(fields[0], `"`))
+		}
+	}
+	return res, scanner.Err()
+}
@@ -14,8 +14,6 @@ var _ = Describe("GoLibraryDetection", func() {
 	}
 
 	expectedLibraries := []string{
-		"fmt",
-		"library1",
 		"gitlab.com/username/reponame/library2",
 		"gitlab.com/username/library3",
 		"gitlab.com/username/reponame/library4",
@@ -24,7 +22,6 @@ var _ = Describe("GoLibraryDetection", func() {
 		"gitlab.com/username/library7",
 		"gitlab.com/username/reponame/library8",
 		"gitlab.com/username/library9",
-		"library10",
 		"gitlab.com/username/reponame/library11",
 		"gitlab.com/username/library12",
 		"gitlab.com/username/reponame/library13",
@@ -45,5 +42,35 @@ var _ = Describe("GoLibraryDetection", func() {
 			}
 			assertSameUnordered(libs, expectedLibraries)
 		})
+
+		It("Should skip the standard library", func() {
+			source := `package server
+
+// #include <stdio.h>
+import "C"
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+import "encoding/json"
+import _ "github.com/lib/pq"
+`
+			libs, err := analyzer.ExtractLibraries(source)
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{
+				"github.com/gorilla/mux",
+				"github.com/sirupsen/logrus",
+				"golang.org/x/sync/errgroup",
+				"github.com/lib/pq",
+			})
+		})
 	})
 })
@@ -0,0 +1,45 @@
+package languages
+
+import (
+	"regexp"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewGradleAnalyzer constructor for build.gradle and build.gradle.kts manifests
+// The libraries are the declared artifacts, like "com.google.guava:guava".
+func NewGradleAnalyzer() librarydetection.Analyzer {
+	return &gradleAnalyzer{}
+}
+
+type gradleAnalyzer struct{}
+
+// gradleConfiguration matches the dependency configurations like implementation, testImplementation,
+// api, compileOnly, runtimeOnly, kapt and the legacy compile and testCompile
+const gradleConfiguration = `(?:\w*(?:[iI]mplementation|[aA]pi|[cC]ompile|[cC]ompileOnly|[rR]untime|[rR]untimeOnly|[aA]nnotationProcessor)|kapt\w*|ksp\w*|classpath)`
+
+func (a *gradleAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// implementation 'com.google.guava:guava:31.0-jre' or implementation("com.google.guava:guava:31.0-jre")
+	regexNotation, err := regexp.Compile(`(?m)^\s*` + gradleConfiguration + `\s*\(?\s*["']([^"':\s]+):([^"':\s]+)(?::[^"']*)?["']`)
+	if err != nil {
+		return nil, err
+	}
+	// implementation group: 'com.google.guava', name: 'guava', version: '31.0-jre'
+	regexMap, err := regexp.Compile(`(?m)^\s*` + gradleConfiguration + `\s*\(?\s*group\s*[:=]\s*["']([^"']+)["']\s*,\s*name\s*[:=]\s*["']([^"']+)["']`)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	seen := map[string]bool{}
+	for _, regex := range []*regexp.Regexp{regexNotation, regexMap} {
+		for _, match := range regex.FindAllStringSubmatch(contents, -1) {
+			library := match[1] + ":" + match[2]
+			if !seen[library] {
+				seen[library] = true
+				res = append(res, library)
+			}
+		}
+	}
+	return res, nil
+}
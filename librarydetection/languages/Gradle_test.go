@@ -0,0 +1,39 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("GradleLibraryDetection", func() {
+	analyzer := languages.NewGradleAnalyzer()
+
+	table.DescribeTable("Should be able to extract the declared dependencies",
+		func(fixturePath string, expectedLibraries []string) {
+			fixture, err := ioutil.ReadFile(fixturePath)
+			if err != nil {
+				panic(err)
+			}
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, expectedLibraries)
+		},
+		table.Entry("build.gradle", "./fixtures/gradle.fixture", []string{
+			"com.google.guava:guava",
+			"org.apache.commons:commons-lang3",
+			"junit:junit",
+			"org.projectlombok:lombok",
+		}),
+		table.Entry("build.gradle.kts", "./fixtures/gradlekts.fixture", []string{
+			"io.ktor:ktor-server-netty",
+			"com.google.dagger:dagger-compiler",
+			"org.postgresql:postgresql",
+		}),
+	)
+})
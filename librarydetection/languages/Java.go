@@ -2,6 +2,7 @@ package languages
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/codersrank-org/repo_info_extractor/librarydetection"
 )
@@ -14,26 +15,37 @@ func NewJavaAnalyzer() librarydetection.Analyzer {
 type javaAnalyzer struct{}
 
 func (a *javaAnalyzer) ExtractLibraries(contents string) ([]string, error) {
-	// regex to find imports like org.springframework (excluding standart java libraries)
-	regex1, err := regexp.Compile(`import ([^java][a-zA-Z0-9]*\.[a-zA-Z0-9]*)`)
-	if err != nil {
-		return nil, err
-	}
-	// regex to find imports like org.springframework.boot
-	regex2, err := regexp.Compile(`import ([^java][a-zA-Z0-9]*\.[a-zA-Z0-9]*\.[a-zA-Z0-9]*)`)
-	if err != nil {
-		return nil, err
-	}
-	// regex to find static imports like org.springframework (excluding standart java libraries)
-	regex3, err := regexp.Compile(`import static ([^java][a-zA-Z0-9]*\.[a-zA-Z0-9]*)`)
-	if err != nil {
-		return nil, err
-	}
-	// regex to find static imports like org.springframework.boot
-	regex4, err := regexp.Compile(`import static ([^java][a-zA-Z0-9]*\.[a-zA-Z0-9]*\.[a-zA-Z0-9]*)`)
+	return extractJVMImports(contents)
+}
+
+// jvmStandardPackages are the roots of the imports coming with the JDK and the Kotlin standard library
+var jvmStandardPackages = map[string]bool{
+	"java":   true,
+	"javax":  true,
+	"kotlin": true,
+}
+
+// extractJVMImports returns the package roots of the Java and Kotlin imports, like org.springframework
+// for import org.springframework.boot.SpringApplication, the standard imports are left out
+func extractJVMImports(contents string) ([]string, error) {
+	// import a.b.C; import a.b.*; import static a.b.C.method; import a.b.C as D
+	regex, err := regexp.Compile(`(?m)^\s*import\s+(?:static\s+)?([\w$]+(?:\.[\w$*]+)*)`)
 	if err != nil {
 		return nil, err
 	}
 
-	return executeRegexes(contents, []*regexp.Regexp{regex1, regex2, regex3, regex4}), nil
+	libraries := []string{}
+	seen := map[string]bool{}
+	for _, match := range regex.FindAllStringSubmatch(contents, -1) {
+		parts := strings.Split(match[1], ".")
+		if len(parts) < 2 || jvmStandardPackages[parts[0]] || parts[1] == "*" {
+			continue
+		}
+		root := parts[0] + "." + parts[1]
+		if !seen[root] {
+			seen[root] = true
+			libraries = append(libraries, root)
+		}
+	}
+	return libraries, nil
 }
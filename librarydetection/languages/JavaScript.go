@@ -2,6 +2,7 @@ package languages
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/codersrank-org/repo_info_extractor/librarydetection"
 )
@@ -14,16 +15,41 @@ func NewJavaScriptAnalyzer() librarydetection.Analyzer {
 type javaScriptAnalyzer struct{}
 
 func (a *javaScriptAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	return extractModuleSpecifiers(contents)
+}
 
-	require, err := regexp.Compile(`require\(["\'](.+)["\']\);?\s`)
+// extractModuleSpecifiers finds the modules of ES module imports and exports, dynamic imports and
+// CommonJS requires, shared by JavaScript and TypeScript
+// Relative imports like "./util" and absolute paths are part of the project, they are left out.
+func extractModuleSpecifiers(contents string) ([]string, error) {
+	// import lib from "lib", import { a, b } from 'lib', import * as lib from "lib", import "lib", import type { T } from "lib"
+	importRegex, err := regexp.Compile(`\bimport\s+(?:[\w$*{},\s]+?\s+from\s+)?["']([^"'\n]+)["']`)
 	if err != nil {
 		return nil, err
 	}
-
-	importRegex, err := regexp.Compile(`import\s*(?:.+ from)?\s?\(?[\'"](.+)[\'"]\)?;?\s`)
+	// export * from "lib", export { a } from 'lib'
+	exportRegex, err := regexp.Compile(`\bexport\s+[\w$*{},\s]+?\s+from\s+["']([^"'\n]+)["']`)
+	if err != nil {
+		return nil, err
+	}
+	// import("lib")
+	dynamicImportRegex, err := regexp.Compile(`\bimport\s*\(\s*["']([^"'\n]+)["']\s*\)`)
+	if err != nil {
+		return nil, err
+	}
+	// require("lib")
+	require, err := regexp.Compile(`\brequire\s*\(\s*["']([^"'\n]+)["']\s*\)`)
 	if err != nil {
 		return nil, err
 	}
 
-	return executeRegexes(contents, []*regexp.Regexp{require, importRegex}), nil
+	specifiers := executeRegexes(contents, []*regexp.Regexp{importRegex, exportRegex, dynamicImportRegex, require})
+	libs := make([]string, 0, len(specifiers))
+	for _, specifier := range specifiers {
+		if strings.HasPrefix(specifier, ".") || strings.HasPrefix(specifier, "/") {
+			continue
+		}
+		libs = append(libs, specifier)
+	}
+	return libs, nil
 }
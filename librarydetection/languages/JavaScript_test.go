@@ -31,5 +31,27 @@ var _ = Describe("JavaScriptLibraryDetection", func() {
 			}
 			assertSameUnordered(libs, expectedLibraries)
 		})
+
+		It("Should only extract the external modules of imports and requires", func() {
+			mixedFixture, err := ioutil.ReadFile("./fixtures/javascriptmixed.fixture")
+			if err != nil {
+				panic(err)
+			}
+			libs, err := analyzer.ExtractLibraries(string(mixedFixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{
+				"react",
+				"d3",
+				"lodash",
+				"normalize.css",
+				"@jest/types",
+				"rxjs/operators",
+				"express",
+				"path",
+				"moment",
+			})
+		})
 	})
 })
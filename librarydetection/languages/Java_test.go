@@ -4,32 +4,32 @@ import (
 	"io/ioutil"
 
 	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
 
 	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
 )
 
 var _ = Describe("JavaLibraryDetection", func() {
-	fixture, err := ioutil.ReadFile("./fixtures/java.fixture")
-	if err != nil {
-		panic(err)
-	}
-
-	expectedLibraries := []string{
-		"org.bytedeco",
-		"org.bytedeco.javacv",
-		"org.bytedeco",
-		"org.bytedeco.opencv",
-	}
-
 	analyzer := languages.NewJavaAnalyzer()
 
-	Describe("Extract Java Libraries", func() {
-		It("Should be able to extract libraries", func() {
+	table.DescribeTable("Should be able to extract libraries",
+		func(fixturePath string, expectedLibraries []string) {
+			fixture, err := ioutil.ReadFile(fixturePath)
+			if err != nil {
+				panic(err)
+			}
 			libs, err := analyzer.ExtractLibraries(string(fixture))
 			if err != nil {
 				panic(err)
 			}
 			assertSameUnordered(libs, expectedLibraries)
-		})
-	})
+		},
+		table.Entry("wildcard and static imports", "./fixtures/java.fixture", []string{"org.bytedeco"}),
+		table.Entry("standard and third party imports", "./fixtures/javaimports.fixture", []string{
+			"org.springframework",
+			"com.google",
+			"org.junit",
+			"org.mockito",
+		}),
+	)
 })
@@ -0,0 +1,47 @@
+package languages
+
+import (
+	"encoding/json"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewPackageJSONAnalyzer constructor for package.json manifests
+// The libraries are the declared packages, like "express".
+func NewPackageJSONAnalyzer() librarydetection.Analyzer {
+	return &packageJSONAnalyzer{}
+}
+
+type packageJSONAnalyzer struct{}
+
+type packageJSON struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+func (a *packageJSONAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	var manifest packageJSON
+	err := json.Unmarshal([]byte(contents), &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	seen := map[string]bool{}
+	for _, dependencies := range []map[string]string{
+		manifest.Dependencies,
+		manifest.DevDependencies,
+		manifest.PeerDependencies,
+		manifest.OptionalDependencies,
+	} {
+		for name := range dependencies {
+			if !seen[name] {
+				seen[name] = true
+				res = append(res, name)
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,35 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("PackageJSONLibraryDetection", func() {
+	analyzer := languages.NewPackageJSONAnalyzer()
+
+	table.DescribeTable("Should be able to extract the declared dependencies",
+		func(fixturePath string, expectedLibraries []string) {
+			fixture, err := ioutil.ReadFile(fixturePath)
+			if err != nil {
+				panic(err)
+			}
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, expectedLibraries)
+		},
+		table.Entry("package.json", "./fixtures/packagejson.fixture", []string{
+			"express",
+			"@babel/runtime",
+			"jest",
+			"react",
+			"fsevents",
+		}),
+	)
+})
@@ -0,0 +1,73 @@
+package languages
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewPackageLockAnalyzer constructor for package-lock.json files
+// The libraries are the resolved packages, like "lodash@4.17.21".
+func NewPackageLockAnalyzer() librarydetection.Analyzer {
+	return &packageLockAnalyzer{}
+}
+
+type packageLockAnalyzer struct{}
+
+// packageLockDependency is an entry of the lockfileVersion 1 dependency tree
+type packageLockDependency struct {
+	Version      string                           `json:"version"`
+	Dependencies map[string]packageLockDependency `json:"dependencies"`
+}
+
+type packageLockPackage struct {
+	Version string `json:"version"`
+}
+
+type packageLock struct {
+	// lockfileVersion 2 and 3, keyed by paths like "node_modules/a/node_modules/b"
+	Packages map[string]packageLockPackage `json:"packages"`
+	// lockfileVersion 1, nested by name
+	Dependencies map[string]packageLockDependency `json:"dependencies"`
+}
+
+func (a *packageLockAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	var lock packageLock
+	err := json.Unmarshal([]byte(contents), &lock)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	seen := map[string]bool{}
+	add := func(name, version string) {
+		library := name + "@" + version
+		if name != "" && version != "" && !seen[library] {
+			seen[library] = true
+			res = append(res, library)
+		}
+	}
+
+	if len(lock.Packages) > 0 {
+		for path, pkg := range lock.Packages {
+			// "" is the project itself, linked workspaces are not under node_modules
+			i := strings.LastIndex(path, "node_modules/")
+			if i == -1 {
+				continue
+			}
+			add(path[i+len("node_modules/"):], pkg.Version)
+		}
+		return res, nil
+	}
+
+	var walk func(dependencies map[string]packageLockDependency)
+	walk = func(dependencies map[string]packageLockDependency) {
+		for name, dependency := range dependencies {
+			add(name, dependency.Version)
+			walk(dependency.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+	return res, nil
+}
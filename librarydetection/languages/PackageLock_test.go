@@ -0,0 +1,49 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("PackageLockLibraryDetection", func() {
+	fixture, err := ioutil.ReadFile("./fixtures/packagelock.fixture")
+	if err != nil {
+		panic(err)
+	}
+	v1Fixture, err := ioutil.ReadFile("./fixtures/packagelockv1.fixture")
+	if err != nil {
+		panic(err)
+	}
+
+	analyzer := languages.NewPackageLockAnalyzer()
+
+	Describe("Extract package-lock.json Libraries", func() {
+		It("Should be able to extract the resolved versions of the packages", func() {
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{
+				"express@4.17.1",
+				"@types/express@4.17.11",
+				"debug@2.6.9",
+			})
+		})
+
+		It("Should be able to extract the resolved versions of lockfileVersion 1", func() {
+			libs, err := analyzer.ExtractLibraries(string(v1Fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{
+				"express@4.17.1",
+				"debug@2.6.9",
+				"debug@4.3.1",
+				"left-pad@1.3.0",
+			})
+		})
+	})
+})
@@ -0,0 +1,24 @@
+package languages
+
+import (
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewPipfileAnalyzer constructor for Pipfile manifests
+// The libraries are the declared packages, like "requests".
+func NewPipfileAnalyzer() librarydetection.Analyzer {
+	return &pipfileAnalyzer{}
+}
+
+type pipfileAnalyzer struct{}
+
+func (a *pipfileAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	var res []string
+	for _, table := range parseTOMLTables(contents) {
+		// [source] and [requires] are not packages
+		if table.name == "packages" || table.name == "dev-packages" {
+			res = append(res, table.keys...)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package languages
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewPomAnalyzer constructor for Maven pom.xml manifests
+// The libraries are the declared artifacts, like "org.springframework:spring-core".
+func NewPomAnalyzer() librarydetection.Analyzer {
+	return &pomAnalyzer{}
+}
+
+type pomAnalyzer struct{}
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+}
+
+type pomProject struct {
+	// the dependencyManagement is left out, the children modules may not use its dependencies
+	Dependencies []pomDependency `xml:"dependencies>dependency"`
+	Profiles     []struct {
+		Dependencies []pomDependency `xml:"dependencies>dependency"`
+	} `xml:"profiles>profile"`
+}
+
+func (a *pomAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	var project pomProject
+	err := xml.Unmarshal([]byte(contents), &project)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := append([]pomDependency{}, project.Dependencies...)
+	for _, profile := range project.Profiles {
+		dependencies = append(dependencies, profile.Dependencies...)
+	}
+
+	var res []string
+	seen := map[string]bool{}
+	for _, dependency := range dependencies {
+		groupID := strings.TrimSpace(dependency.GroupID)
+		artifactID := strings.TrimSpace(dependency.ArtifactID)
+		if groupID == "" || artifactID == "" {
+			continue
+		}
+		library := groupID + ":" + artifactID
+		if !seen[library] {
+			seen[library] = true
+			res = append(res, library)
+		}
+	}
+	return res, nil
+}
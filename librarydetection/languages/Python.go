@@ -2,6 +2,7 @@ package languages
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/codersrank-org/repo_info_extractor/librarydetection"
 )
@@ -13,15 +14,44 @@ func NewPythonScriptAnalyzer() librarydetection.Analyzer {
 
 type pythonScriptAnalyzer struct{}
 
+// ExtractLibraries returns the top-level modules of the imports, relative imports are left out
 func (a *pythonScriptAnalyzer) ExtractLibraries(contents string) ([]string, error) {
-	fromRegex, err := regexp.Compile(`from (.+) import`)
+	// from foo.bar import baz
+	fromRegex, err := regexp.Compile(`(?m)^\s*from\s+([\w.]+)\s+import\b`)
 	if err != nil {
 		return nil, err
 	}
-	importRegex, err := regexp.Compile(`import ([a-zA-Z0-9_-]+)(?:\s| as)`)
+	// import foo, bar.baz as qux
+	importRegex, err := regexp.Compile(`(?m)^\s*import\s+([\w., \t]+)`)
 	if err != nil {
 		return nil, err
 	}
 
-	return executeRegexes(contents, []*regexp.Regexp{fromRegex, importRegex}), nil
+	modules := []string{}
+	for _, match := range fromRegex.FindAllStringSubmatch(contents, -1) {
+		modules = append(modules, match[1])
+	}
+	for _, match := range importRegex.FindAllStringSubmatch(contents, -1) {
+		for _, module := range strings.Split(match[1], ",") {
+			// Drop the alias
+			fields := strings.Fields(module)
+			if len(fields) > 0 {
+				modules = append(modules, fields[0])
+			}
+		}
+	}
+
+	libraries := []string{}
+	seen := map[string]bool{}
+	for _, module := range modules {
+		if strings.HasPrefix(module, ".") {
+			continue
+		}
+		topLevel := strings.Split(module, ".")[0]
+		if !seen[topLevel] {
+			seen[topLevel] = true
+			libraries = append(libraries, topLevel)
+		}
+	}
+	return libraries, nil
 }
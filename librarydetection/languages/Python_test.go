@@ -14,10 +14,19 @@ var _ = Describe("PythonLibraryDetection", func() {
 		panic(err)
 	}
 
+	noImportsFixture, err := ioutil.ReadFile("./fixtures/pythonnoimports.fixture")
+	if err != nil {
+		panic(err)
+	}
+
 	expectedLibraries := []string{
-		"lib1.lib2",
-		"lib3",
+		"lib1",
 		"lib4",
+		"lib5",
+		"lib6",
+		"lib7",
+		"lib8",
+		"lib9",
 	}
 
 	analyzer := languages.NewPythonScriptAnalyzer()
@@ -30,5 +39,13 @@ var _ = Describe("PythonLibraryDetection", func() {
 			}
 			assertSameUnordered(libs, expectedLibraries)
 		})
+
+		It("Should find nothing without imports", func() {
+			libs, err := analyzer.ExtractLibraries(string(noImportsFixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{})
+		})
 	})
 })
@@ -0,0 +1,45 @@
+package languages
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewRequirementsAnalyzer constructor for pip requirements.txt files
+// The libraries are the required distributions, like "requests".
+func NewRequirementsAnalyzer() librarydetection.Analyzer {
+	return &requirementsAnalyzer{}
+}
+
+type requirementsAnalyzer struct{}
+
+func (a *requirementsAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// the name before the extras, the version specifiers, the markers or the direct reference, like
+	// requests[security]>=2.8.1 ; python_version < "3.8"
+	// mylib @ https://example.com/mylib-1.0.zip
+	regexName, err := regexp.Compile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:$|[\[<>=!~;@ ])`)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		// options like -r other.txt, -e ./local or --index-url are not requirements
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if match := regexName.FindStringSubmatch(line); match != nil {
+			res = append(res, match[1])
+		}
+	}
+	return res, scanner.Err()
+}
@@ -0,0 +1,39 @@
+package languages
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewRubyAnalyzer constructor
+func NewRubyAnalyzer() librarydetection.Analyzer {
+	return &rubyAnalyzer{}
+}
+
+type rubyAnalyzer struct{}
+
+// ExtractLibraries returns the top-level names of the required files, relative requires are left out
+func (a *rubyAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// require 'foo/bar' or require("foo"), require_relative doesn't match
+	regex, err := regexp.Compile(`(?m)^\s*require(?:\s+|\s*\(\s*)["']([^"']+)["']`)
+	if err != nil {
+		return nil, err
+	}
+
+	libraries := []string{}
+	seen := map[string]bool{}
+	for _, match := range regex.FindAllStringSubmatch(contents, -1) {
+		path := match[1]
+		if strings.HasPrefix(path, ".") || strings.HasPrefix(path, "/") {
+			continue
+		}
+		topLevel := strings.Split(path, "/")[0]
+		if !seen[topLevel] {
+			seen[topLevel] = true
+			libraries = append(libraries, topLevel)
+		}
+	}
+	return libraries, nil
+}
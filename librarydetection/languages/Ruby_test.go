@@ -0,0 +1,36 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("RubyLibraryDetection", func() {
+	fixture, err := ioutil.ReadFile("./fixtures/ruby.fixture")
+	if err != nil {
+		panic(err)
+	}
+
+	expectedLibraries := []string{
+		"json",
+		"net",
+		"active_support",
+		"nokogiri",
+		"yaml",
+	}
+
+	analyzer := languages.NewRubyAnalyzer()
+
+	Describe("Extract Ruby Libraries", func() {
+		It("Should be able to extract libraries", func() {
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, expectedLibraries)
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package languages
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewRustAnalyzer constructor
+func NewRustAnalyzer() librarydetection.Analyzer {
+	return &rustAnalyzer{}
+}
+
+type rustAnalyzer struct{}
+
+// rustBuiltinCrates are the crates coming with the compiler and the paths relative to the current crate
+var rustBuiltinCrates = map[string]bool{
+	"std":   true,
+	"core":  true,
+	"alloc": true,
+	"self":  true,
+	"crate": true,
+	"super": true,
+}
+
+// ExtractLibraries returns the crate roots of the use declarations and the extern crates
+func (a *rustAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// use serde::Serialize; pub(crate) use tokio::{runtime, sync::mpsc}; use {rand, log::info};
+	regexUse, err := regexp.Compile(`(?m)^\s*(?:pub(?:\s*\([^)]*\))?\s+)?use\s+([^;]+);`)
+	if err != nil {
+		return nil, err
+	}
+	// extern crate serde_json as json;
+	regexExtern, err := regexp.Compile(`(?m)^\s*(?:pub(?:\s*\([^)]*\))?\s+)?extern\s+crate\s+(\w+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	libraries := []string{}
+	seen := map[string]bool{}
+	add := func(crate string) {
+		if crate == "" || rustBuiltinCrates[crate] || seen[crate] {
+			return
+		}
+		seen[crate] = true
+		libraries = append(libraries, crate)
+	}
+	for _, match := range regexUse.FindAllStringSubmatch(contents, -1) {
+		for _, crate := range rustUseRoots(match[1]) {
+			add(crate)
+		}
+	}
+	for _, crate := range executeRegexes(contents, []*regexp.Regexp{regexExtern}) {
+		add(crate)
+	}
+	return libraries, nil
+}
+
+// rustUseRoots returns the crate roots of a use tree, like tokio for tokio::{runtime, sync::mpsc}
+// and both rand and log for {rand, log::info}
+func rustUseRoots(tree string) []string {
+	tree = strings.TrimPrefix(strings.TrimSpace(tree), "::")
+	if !strings.HasPrefix(tree, "{") {
+		// serde::Serialize, serde as s
+		root := strings.Fields(strings.SplitN(tree, "::", 2)[0])
+		if len(root) == 0 {
+			return nil
+		}
+		return []string{root[0]}
+	}
+
+	// split the top-level group at the commas which aren't in a nested group
+	roots := []string{}
+	depth := 0
+	start := 1
+	for i, c := range tree {
+		switch c {
+		case '{':
+			depth++
+			continue
+		case '}':
+			depth--
+			if depth > 0 {
+				continue
+			}
+		case ',':
+			if depth > 1 {
+				continue
+			}
+		default:
+			continue
+		}
+		roots = append(roots, rustUseRoots(tree[start:i])...)
+		start = i + 1
+		if depth == 0 {
+			return roots
+		}
+	}
+	return roots
+}
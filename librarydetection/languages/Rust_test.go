@@ -0,0 +1,40 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("RustLibraryDetection", func() {
+	fixture, err := ioutil.ReadFile("./fixtures/rust.fixture")
+	if err != nil {
+		panic(err)
+	}
+
+	expectedLibraries := []string{
+		"lazy_static",
+		"serde_json",
+		"serde",
+		"tokio",
+		"anyhow",
+		"rand",
+		"log",
+		"regex",
+		"clap",
+	}
+
+	analyzer := languages.NewRustAnalyzer()
+
+	Describe("Extract Rust Libraries", func() {
+		It("Should be able to extract libraries", func() {
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, expectedLibraries)
+		})
+	})
+})
@@ -0,0 +1,54 @@
+package languages
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewSwiftPackageAnalyzer constructor for Package.swift manifests
+func NewSwiftPackageAnalyzer() librarydetection.Analyzer {
+	return &swiftPackageAnalyzer{}
+}
+
+type swiftPackageAnalyzer struct{}
+
+func (a *swiftPackageAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// regex to find the arguments of dependencies like .package(url: "...", from: "1.0.0")
+	// version requirements like .upToNextMajor(from: "1.0.0") come after the name and the url
+	regexPackage, err := regexp.Compile(`(?s)\.package\s*\((.*?)\)`)
+	if err != nil {
+		return nil, err
+	}
+	regexName, err := regexp.Compile(`name:\s*"([^"]+)"`)
+	if err != nil {
+		return nil, err
+	}
+	regexURL, err := regexp.Compile(`url:\s*"([^"]+)"`)
+	if err != nil {
+		return nil, err
+	}
+	// packages from a registry like .package(id: "scope.name", from: "1.0.0")
+	regexID, err := regexp.Compile(`id:\s*"([^"]+)"`)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, match := range regexPackage.FindAllStringSubmatch(contents, -1) {
+		arguments := match[1]
+		if name := regexName.FindStringSubmatch(arguments); name != nil {
+			res = append(res, name[1])
+		} else if url := regexURL.FindStringSubmatch(arguments); url != nil {
+			// the package is named after the repository
+			res = append(res, strings.TrimSuffix(path.Base(strings.TrimRight(url[1], "/")), ".git"))
+		} else if id := regexID.FindStringSubmatch(arguments); id != nil {
+			res = append(res, id[1])
+		}
+		// local packages like .package(path: "../Local") are not libraries
+	}
+
+	return res, nil
+}
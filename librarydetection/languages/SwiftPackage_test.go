@@ -0,0 +1,36 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("SwiftPackageLibraryDetection", func() {
+	fixture, err := ioutil.ReadFile("./fixtures/swiftpackage.fixture")
+	if err != nil {
+		panic(err)
+	}
+
+	expectedLibraries := []string{
+		"swift-nio",
+		"vapor",
+		"Firebase",
+		"Alamofire",
+		"mona.LinkedList",
+	}
+
+	analyzer := languages.NewSwiftPackageAnalyzer()
+
+	Describe("Extract Swift Package Libraries", func() {
+		It("Should be able to extract libraries", func() {
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, expectedLibraries)
+		})
+	})
+})
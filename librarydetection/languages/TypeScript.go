@@ -1,8 +1,6 @@
 package languages
 
 import (
-	"regexp"
-
 	"github.com/codersrank-org/repo_info_extractor/librarydetection"
 )
 
@@ -14,14 +12,5 @@ func NewTypeScriptAnalyzer() librarydetection.Analyzer {
 type typeScriptAnalyzer struct{}
 
 func (a *typeScriptAnalyzer) ExtractLibraries(contents string) ([]string, error) {
-	require, err := regexp.Compile(`require\(["\'](.+)["\']\);?\s`)
-	if err != nil {
-		return nil, err
-	}
-	importRegex, err := regexp.Compile(`import\s*(?:.+ from)?\s?\(?[\'"](.+)[\'"]\)?;?\s`)
-	if err != nil {
-		return nil, err
-	}
-
-	return executeRegexes(contents, []*regexp.Regexp{require, importRegex}), nil
+	return extractModuleSpecifiers(contents)
 }
@@ -0,0 +1,70 @@
+package languages
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewYarnLockAnalyzer constructor for yarn.lock files
+// The libraries are the resolved packages, like "lodash@4.17.21".
+func NewYarnLockAnalyzer() librarydetection.Analyzer {
+	return &yarnLockAnalyzer{}
+}
+
+type yarnLockAnalyzer struct{}
+
+func (a *yarnLockAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// Entries start with the requested ranges, followed by the indented fields:
+	//   "@babel/core@^7.0.0", "@babel/core@^7.1.0":   (yarn 1)
+	//     version "7.1.2"
+	//   "lodash@npm:^4.17.21":                        (yarn 2+)
+	//     version: 4.17.21
+	var res []string
+	seen := map[string]bool{}
+	name := ""
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			name = yarnPackageName(strings.TrimSuffix(trimmed, ":"))
+			continue
+		}
+		if name == "" || !strings.HasPrefix(trimmed, "version") {
+			continue
+		}
+		version := strings.TrimPrefix(trimmed, "version")
+		version = strings.Trim(strings.TrimSpace(strings.TrimPrefix(version, ":")), `"`)
+		if version == "" {
+			continue
+		}
+		library := name + "@" + version
+		if !seen[library] {
+			seen[library] = true
+			res = append(res, library)
+		}
+		name = ""
+	}
+	return res, scanner.Err()
+}
+
+// yarnPackageName returns the package name of the first range in an entry header
+// e.g. "@babel/core@^7.0.0", "@babel/core@^7.1.0" -> @babel/core
+func yarnPackageName(header string) string {
+	first := strings.TrimSpace(strings.Split(header, ",")[0])
+	first = strings.Trim(first, `"`)
+	if first == "__metadata" {
+		return ""
+	}
+	// Scoped packages start with @, the range starts at the last @
+	at := strings.LastIndex(first, "@")
+	if at <= 0 {
+		return ""
+	}
+	return first[:at]
+}
@@ -0,0 +1,48 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("YarnLockLibraryDetection", func() {
+	fixture, err := ioutil.ReadFile("./fixtures/yarnlock.fixture")
+	if err != nil {
+		panic(err)
+	}
+	berryFixture, err := ioutil.ReadFile("./fixtures/yarnberrylock.fixture")
+	if err != nil {
+		panic(err)
+	}
+
+	analyzer := languages.NewYarnLockAnalyzer()
+
+	Describe("Extract yarn.lock Libraries", func() {
+		It("Should be able to extract the resolved versions of yarn 1", func() {
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{
+				"@babel/code-frame@7.10.4",
+				"lodash@4.17.21",
+				"lodash@3.10.1",
+				"react@17.0.1",
+			})
+		})
+
+		It("Should be able to extract the resolved versions of yarn 2", func() {
+			libs, err := analyzer.ExtractLibraries(string(berryFixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, []string{
+				"@types/node@14.14.20",
+				"typescript@4.1.3",
+			})
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package languages
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
 // executeRegexes is a helper function that executes a number of regexes and assumes each of them returns 1 group only
 func executeRegexes(contents string, regexes []*regexp.Regexp) []string {
@@ -16,3 +19,41 @@ func executeRegexes(contents string, regexes []*regexp.Regexp) []string {
 	}
 	return res
 }
+
+// tomlTable is a table of a TOML document with its keys, like [dependencies]
+type tomlTable struct {
+	name string
+	keys []string
+}
+
+// parseTOMLTables returns the tables of a TOML document in order, the keys before the first table
+// are in a table with an empty name. The values are skipped, it is enough for manifests like
+// Cargo.toml and Pipfile which declare the dependencies as keys.
+func parseTOMLTables(contents string) []tomlTable {
+	tables := []tomlTable{{}}
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			// arrays of tables like [[bin]] are never dependencies
+			name := ""
+			if !strings.HasPrefix(line, "[[") {
+				if end := strings.LastIndex(line, "]"); end != -1 {
+					name = strings.TrimSpace(line[1:end])
+				}
+			}
+			tables = append(tables, tomlTable{name: name})
+			continue
+		}
+		// the lines of the multi-line values have no key
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			continue
+		}
+		key := strings.Trim(strings.TrimSpace(line[:i]), `"'`)
+		tables[len(tables)-1].keys = append(tables[len(tables)-1].keys, key)
+	}
+	return tables
+}
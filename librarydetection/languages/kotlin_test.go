@@ -4,30 +4,32 @@ import (
 	"io/ioutil"
 
 	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
 
 	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
 )
 
-var _ = Describe("CLibraryDetection", func() {
-	fixture, err := ioutil.ReadFile("./fixtures/kotlin.fixture")
-	if err != nil {
-		panic(err)
-	}
-
-	expectedLibraries := []string{
-		"syntax.lexer.Token",
-		"syntax.tree",
-	}
-
+var _ = Describe("KotlinLibraryDetection", func() {
 	analyzer := languages.NewKotlinAnalyzer()
 
-	Describe("Extract C Libraries", func() {
-		It("Should be able to extract libraries", func() {
+	table.DescribeTable("Should be able to extract libraries",
+		func(fixturePath string, expectedLibraries []string) {
+			fixture, err := ioutil.ReadFile(fixturePath)
+			if err != nil {
+				panic(err)
+			}
 			libs, err := analyzer.ExtractLibraries(string(fixture))
 			if err != nil {
 				panic(err)
 			}
 			assertSameUnordered(libs, expectedLibraries)
-		})
-	})
+		},
+		table.Entry("wildcard imports", "./fixtures/kotlin.fixture", []string{"syntax.lexer", "syntax.tree"}),
+		table.Entry("standard, aliased and third party imports", "./fixtures/kotlinimports.fixture", []string{
+			"kotlinx.coroutines",
+			"io.ktor",
+			"org.jetbrains",
+			"com.fasterxml",
+		}),
+	)
 })
@@ -7,14 +7,14 @@ import (
 	"github.com/codersrank-org/repo_info_extractor/librarydetection"
 )
 
-// NewCAnalyzer constructor
+// NewPHPAnalyzer constructor
 func NewPHPAnalyzer() librarydetection.Analyzer {
 	return &phpAnalyzer{}
 }
 
-type phpAnalyzer struct {}
+type phpAnalyzer struct{}
 
-func (a *phpAnalyzer) ExtractLibraries(contents string) ([]string , error){
+func (a *phpAnalyzer) ExtractLibraries(contents string) ([]string, error) {
 	// matches
 	// require('lib1');
 	// require 'lib2';
@@ -28,25 +28,54 @@ func (a *phpAnalyzer) ExtractLibraries(contents string) ([]string , error){
 	// require_once 'lib10';
 	// require_once("lib11");
 	// require_once "lib12";
-	regex1, err := regexp.Compile(`(?i)(?:require|require_once|include)[( ]{1}['"]([a-zA-Z0-9]+)["'][)]?;`)
+	// include_once "lib13";
+	regex1, err := regexp.Compile(`(?i)(?:require|require_once|include|include_once)[( ]{1}['"]([a-zA-Z0-9]+)["'][)]?;`)
 	if err != nil {
 		return nil, err
 	}
 
-	// match all `use` imports
-	regex2, err := regexp.Compile(`(?i)use ([a-zA-Z]+\\)[^;]*`)
+	// match the namespaced `use` imports, like
+	// use Illuminate\Http\UploadedFile;
+	// use function GuzzleHttp\Promise\all;
+	// use Symfony\Component\{Console, Yaml\Yaml}, Monolog\Logger as Log;
+	regex2, err := regexp.Compile(`(?im)^\s*use\s+(?:function\s+|const\s+)?([^;]+\\[^;]*);`)
 	if err != nil {
 		return nil, err
 	}
 
-	ret := executeRegexes(contents, []*regexp.Regexp{regex1, regex2})
-
-	var res []string
-	for _, v := range ret {
-		if !strings.HasPrefix(v, "App") {
-			res = append(res, v)
+	res := executeRegexes(contents, []*regexp.Regexp{regex1})
+	seen := map[string]bool{}
+	for _, declaration := range executeRegexes(contents, []*regexp.Regexp{regex2}) {
+		for _, root := range phpUseRoots(declaration) {
+			// App is the namespace of the application itself
+			if root == "App" || seen[root] {
+				continue
+			}
+			seen[root] = true
+			res = append(res, root)
 		}
 	}
 
 	return res, nil
 }
+
+// phpUseRoots returns the vendor roots of the namespaces of a use declaration,
+// like Symfony and Monolog for Symfony\Component\{Console, Yaml\Yaml}, Monolog\Logger
+func phpUseRoots(declaration string) []string {
+	// the names of a group share the prefix, their commas don't separate the imports
+	if i := strings.Index(declaration, "{"); i != -1 {
+		if j := strings.Index(declaration[i:], "}"); j != -1 {
+			declaration = declaration[:i] + declaration[i+j+1:]
+		}
+	}
+
+	var roots []string
+	for _, name := range strings.Split(declaration, ",") {
+		name = strings.TrimLeft(strings.TrimSpace(name), "\\")
+		if !strings.Contains(name, "\\") {
+			continue
+		}
+		roots = append(roots, strings.SplitN(name, "\\", 2)[0])
+	}
+	return roots
+}
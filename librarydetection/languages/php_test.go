@@ -8,7 +8,7 @@ import (
 	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
 )
 
-var _ = Describe("CLibraryDetection", func() {
+var _ = Describe("PHPLibraryDetection", func() {
 	fixture, err := ioutil.ReadFile("./fixtures/php.fixture")
 	if err != nil {
 		panic(err)
@@ -27,12 +27,17 @@ var _ = Describe("CLibraryDetection", func() {
 		"lib10",
 		"lib11",
 		"lib12",
-		"Illuminate\\",
+		"lib13",
+		"Illuminate",
+		"GuzzleHttp",
+		"Monolog",
+		"Symfony",
+		"Carbon",
 	}
 
 	analyzer := languages.NewPHPAnalyzer()
 
-	Describe("Extract C Libraries", func() {
+	Describe("Extract PHP Libraries", func() {
 		It("Should be able to extract libraries", func() {
 			libs, err := analyzer.ExtractLibraries(string(fixture))
 			if err != nil {
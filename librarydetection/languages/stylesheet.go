@@ -0,0 +1,65 @@
+package languages
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection"
+)
+
+// NewStylesheetAnalyzer constructor for CSS, SCSS, SASS and Less
+func NewStylesheetAnalyzer() librarydetection.Analyzer {
+	return &stylesheetAnalyzer{}
+}
+
+type stylesheetAnalyzer struct{}
+
+func (a *stylesheetAnalyzer) ExtractLibraries(contents string) ([]string, error) {
+	// commented out rules like /* @import "old"; */ are not dependencies
+	regexBlockComment, err := regexp.Compile(`(?s)/\*.*?\*/`)
+	if err != nil {
+		return nil, err
+	}
+	regexLineComment, err := regexp.Compile(`(?m)^\s*//.*$`)
+	if err != nil {
+		return nil, err
+	}
+	// regex to find the arguments of rules like @import "a", "b"; they can be nested in blocks
+	// the indented SASS syntax has no semicolons, the rule ends with the line
+	regexRule, err := regexp.Compile(`@(import|use|forward)\s+([^;{}\n]+)`)
+	if err != nil {
+		return nil, err
+	}
+	// paths like "a", 'a' or url(a)
+	regexPath, err := regexp.Compile(`url\(\s*["']?([^"')]+?)["']?\s*\)|"([^"]+)"|'([^']+)'`)
+	if err != nil {
+		return nil, err
+	}
+
+	contents = regexBlockComment.ReplaceAllString(contents, "")
+	contents = regexLineComment.ReplaceAllString(contents, "")
+
+	var res []string
+	for _, match := range regexRule.FindAllStringSubmatch(contents, -1) {
+		rule, arguments := match[1], match[2]
+		paths := regexPath.FindAllStringSubmatch(arguments, -1)
+		if len(paths) == 0 {
+			// unquoted imports of the indented SASS syntax like @import reset, colors
+			for _, path := range strings.Split(arguments, ",") {
+				if fields := strings.Fields(path); len(fields) > 0 {
+					res = append(res, fields[0])
+				}
+			}
+			continue
+		}
+		// @use and @forward take one path, the quotes after it are like @use "a" with ($font: "Arial")
+		if rule != "import" {
+			paths = paths[:1]
+		}
+		for _, path := range paths {
+			res = append(res, path[1]+path[2]+path[3])
+		}
+	}
+
+	return res, nil
+}
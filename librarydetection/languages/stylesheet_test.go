@@ -0,0 +1,53 @@
+package languages_test
+
+import (
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+
+	"github.com/codersrank-org/repo_info_extractor/librarydetection/languages"
+)
+
+var _ = Describe("StylesheetLibraryDetection", func() {
+	analyzer := languages.NewStylesheetAnalyzer()
+
+	table.DescribeTable("Should be able to extract libraries",
+		func(fixturePath string, expectedLibraries []string) {
+			fixture, err := ioutil.ReadFile(fixturePath)
+			if err != nil {
+				panic(err)
+			}
+			libs, err := analyzer.ExtractLibraries(string(fixture))
+			if err != nil {
+				panic(err)
+			}
+			assertSameUnordered(libs, expectedLibraries)
+		},
+		table.Entry("SCSS", "./fixtures/scss.fixture", []string{
+			"sass:math",
+			"src/corners",
+			"library",
+			"src/list",
+			"bootstrap/scss/functions",
+			"bootstrap/scss/variables",
+			"https://fonts.googleapis.com/css?family=Roboto",
+			"theme.css",
+			"alert/base",
+			"alert/title",
+		}),
+		table.Entry("Less", "./fixtures/less.fixture", []string{
+			"mixins.less",
+			"print",
+			"reset.css",
+			"box/sizes",
+		}),
+		table.Entry("SASS", "./fixtures/sass.fixture", []string{
+			"sass:color",
+			"reset",
+			"typography",
+			"../node_modules/normalize.css/normalize",
+			"nav/links",
+		}),
+	)
+})
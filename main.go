@@ -17,21 +17,50 @@ func main() {
 	au.CheckUpdates()
 
 	repoPath := flag.String("repo_path", "", "Path of the repo")
+	remoteURL := flag.String("remote_url", "", "URL of the repo. It is cloned into a temporary directory if repo_path is not set.")
+	remoteName := flag.String("remote_name", "", "Remote defining the name of the repo. Defaults to origin.")
+	gitDir := flag.String("git_dir", "", "Git directory of the repo, if it is separated from the work tree.")
+	workTree := flag.String("work_tree", "", "Work tree of the repo, if it is separated from the git directory.")
+	ref := flag.String("ref", "", "Branch, tag, commit or range like v1.0..v2.0 to analyse. Defaults to every ref.")
+	fullClone := flag.Bool("full_clone", false, "Clone the full history of remote_url instead of a shallow clone.")
 	// Following two flags should be used to disable email prompt
 	// Program is going to ask you to choose your emails
 	// But if you want, you can provide the emails yourself
 	headless := flag.String("headless", "false", "Headless mode is used on CodersRank's backend system.")
+	upload := flag.Bool("upload", false, "Uploads the results to CodersRank, even in headless mode.")
+	noUpload := flag.Bool("no_upload", false, "Doesn't upload the results to CodersRank, even in interactive mode.")
+	yes := flag.Bool("yes", false, "Uploads the results without asking for confirmation.")
 	obfuscate := flag.String("obfuscate", "true", "Set it to true for debug purposes.")
-	outputPath := flag.String("output_path", "./repo_data_v2", "Where to put output file")
+	outputPath := flag.String("output_path", "", "Where to put output file. Defaults to "+extractor.DefaultOutputPath+".")
 	gitPath := flag.String("git_path", "", "Where is git binary?")
-	emailString := flag.String("emails", "", "Predefined emails. Example: \"alim.giray@codersrank.io,alimgiray@gmail.com\"")
+	emailString := flag.String("emails", "", "Predefined emails. Example: \"alim.giray@codersrank.io,alimgiray@gmail.com\". In headless mode defaults to the CODERSRANK_EMAILS environment variable.")
 	seeds := flag.String("seeds", "", "The seed is used to find similar emails. Example: \"alimgiray, alimgiray@codersrank.io\"")
 	repoName := flag.String("repo_name", "", "You can overwrite the default repo name. This name will be shown on the profile page.")
+	concurrency := flag.Int("concurrency", 0, "Number of workers reading the commits and detecting the libraries. Defaults to the number of CPUs.")
 	skipLibraries := flag.Bool("skip_libraries", false, "Turns off the library detection in order to reduce the execution time.")
+	useMailmap := flag.Bool("use_mailmap", true, "Maps the author names and emails with .mailmap.")
+	authorMapping := flag.String("author_mapping", "", "CSV file mapping emails to canonical identities. Every row is \"raw email,canonical email[,canonical name]\".")
+	userCommitsOnly := flag.Bool("user_commits_only", false, "Lets git log read only the commits of the given emails, which is faster in large repos. The languages introduced by the user are left out.")
+	listEmails := flag.Bool("list_emails", false, "Prints the emails of the repo with their number of commits as JSON and exits.")
+	outputLayout := flag.String("output_layout", "", "\"single\" (default) writes every commit into one file, \"per-language\" writes the changed files into one file per language.")
+	outputFormat := flag.String("output_format", "", "\"zip\" (default), \"gzip\" or \"jsonl\".")
+	since := flag.String("since", "", "Only the commits after this date are read, e.g. 2020-01-01.")
+	maxCommits := flag.Int("max_commits", 0, "Only the most recent commits are read if it is positive. It can't be combined with -incremental.")
+	commandTimeout := flag.Duration("command_timeout", 0, "The git commands reading the commits and the files are killed after this long, a git log fails the extraction and the files are skipped, e.g. 2m. Defaults to "+extractor.DefaultCommandTimeout.String()+", negative turns it off.")
+	verbose := flag.Bool("verbose", false, "Logs every commit and file. The messages go to stderr.")
+	incremental := flag.Bool("incremental", false, "Only reads the commits after the last incremental run and appends them to its results, which are saved next to the cursor file.")
+	cursorPath := flag.String("cursor_path", "", "Cursor file of the incremental runs. Defaults to <output_path>.repo.cursor.")
+	configPath := flag.String("config", "", "JSON file with the emails, concurrency, exclude patterns, since date and output settings. The flags override it.")
 	flag.Parse()
 
-	if repoPath == nil || *repoPath == "" {
-		panic("Please provide a path to the repo")
+	// The paths after the flags are extracted one after the other
+	repoPaths := flag.Args()
+	if *repoPath != "" && len(repoPaths) > 0 {
+		repoPaths = append([]string{*repoPath}, repoPaths...)
+	}
+
+	if *repoPath == "" && *remoteURL == "" && *gitDir == "" && len(repoPaths) == 0 {
+		panic("Please provide a path or a URL to the repo")
 	}
 
 	emails := make([]string, 0)
@@ -46,18 +75,47 @@ func main() {
 
 	repoExtractor := extractor.RepoExtractor{
 		RepoPath:            *repoPath,
+		RemoteURL:           *remoteURL,
+		RemoteName:          *remoteName,
+		Ref:                 *ref,
+		SinceDate:           *since,
+		MaxCommits:          *maxCommits,
+		ConfigPath:          *configPath,
+		Incremental:         *incremental,
+		CursorPath:          *cursorPath,
+		GitDir:              *gitDir,
+		WorkTree:            *workTree,
+		FullClone:           *fullClone,
 		OutputPath:          *outputPath,
 		GitPath:             *gitPath,
+		Version:             version,
 		Headless:            *headless == "true",
+		Upload:              *upload,
+		NoUpload:            *noUpload,
+		AssumeYes:           *yes,
 		Obfuscate:           *obfuscate == "true",
 		UserEmails:          emails,
 		Seed:                seed,
 		ShowProgressBar:     *headless != "true", // Show progress bar only if running in interactive mode
 		OverwrittenRepoName: *repoName,
 		SkipLibraries:       *skipLibraries,
+		Concurrency:         *concurrency,
+		OutputLayout:        *outputLayout,
+		OutputFormat:        *outputFormat,
+		ListEmailsOnly:      *listEmails,
+		SkipMailmap:         !*useMailmap,
+		AuthorMappingPath:   *authorMapping,
+		UserCommitsOnly:     *userCommitsOnly,
+		CommandTimeout:      *commandTimeout,
+		Verbose:             *verbose,
 	}
 
-	err := repoExtractor.Extract()
+	var err error
+	if len(repoPaths) > 0 {
+		err = repoExtractor.BatchExtract(repoPaths)
+	} else {
+		err = repoExtractor.Extract()
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -12,19 +12,42 @@ import (
 func Obfuscate(c *commit.Commit) *commit.Commit {
 	c.AuthorEmail = toMD5(c.AuthorEmail)
 	c.AuthorName = toMD5(c.AuthorName)
+	if c.AuthorDomain != "" {
+		c.AuthorDomain = toMD5(c.AuthorDomain)
+	}
+	// Hashing the message wouldn't be useful, leave it out instead, but keep which commits share a reference
+	c.Subject = ""
+	c.Body = ""
+	for i, reference := range c.References {
+		c.References[i] = toMD5(reference)
+	}
+	for i := range c.Reviewers {
+		c.Reviewers[i].Email = toMD5(c.Reviewers[i].Email)
+		c.Reviewers[i].Name = toMD5(c.Reviewers[i].Name)
+	}
+	for i := range c.CoAuthors {
+		c.CoAuthors[i].Email = toMD5(c.CoAuthors[i].Email)
+		c.CoAuthors[i].Name = toMD5(c.CoAuthors[i].Name)
+	}
 	for _, filechange := range c.ChangedFiles {
-		filechange.Path = obfuscateFile(filechange.Path)
+		filechange.Path = ObfuscatePath(filechange.Path)
 	}
 	return c
 }
 
+// ObfuscateText hashes names and emails the same way as Obfuscate
+func ObfuscateText(text string) string {
+	return toMD5(text)
+}
+
 func toMD5(text string) string {
 	algorithm := md5.New()
 	algorithm.Write([]byte(text))
 	return hex.EncodeToString(algorithm.Sum(nil))
 }
 
-func obfuscateFile(path string) string {
+// ObfuscatePath hashes every part of the path, but leaves the extensions
+func ObfuscatePath(path string) string {
 	obfuscatedPath := ""
 	dirs := strings.Split(path, "/")
 	for i, dir := range dirs {
@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+)
+
+// ConfirmUpload shows a CLI confirm interface.
+// The user decides whether the results are uploaded to the given URL.
+// The returning value is true if the user agreed.
+func ConfirmUpload(url string) bool {
+	upload := false
+	prompt := &survey.Confirm{
+		Message: "Do you want to upload the results to " + url + "?",
+		Help:    "The results contain the stats of your commits, the changed file paths and the detected libraries.",
+	}
+	err := survey.AskOne(prompt, &upload)
+	if err == terminal.InterruptErr {
+		os.Exit(0)
+	}
+	return err == nil && upload
+}
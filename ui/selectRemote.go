@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+)
+
+// SelectRemote shows a CLI select interface.
+// The user chooses the remote which defines the name of the repo.
+// The returning value is the selected remote.
+func SelectRemote(remotes []string) string {
+	selectedRemote := ""
+	prompt := &survey.Select{
+		Message: "Please choose the remote of the repo:",
+		Options: remotes,
+	}
+	err := survey.AskOne(prompt, &selectedRemote)
+	if err == terminal.InterruptErr {
+		os.Exit(0)
+	}
+	return selectedRemote
+}